@@ -1,13 +1,20 @@
 module github.com/StevenACoffman/fixer
 
-go 1.16
+go 1.22.0
 
 require (
 	github.com/Djarvur/go-err113 v0.1.0
 	github.com/kyoh86/exportloopref v0.1.8
 	github.com/nishanths/exhaustive v0.7.11
 	github.com/ssgreg/nlreturn/v2 v2.2.1
-	golang.org/x/sys v0.0.0-20220209214540-3681064d5158 // indirect
-	golang.org/x/tools v0.1.9
+	github.com/vektah/gqlparser/v2 v2.5.1
+	golang.org/x/tools v0.30.0
 	honnef.co/go/tools v0.2.2
 )
+
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	github.com/agnivade/levenshtein v1.0.1 // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -0,0 +1,141 @@
+package main
+
+// This file implements the `fixer graphql-ops` subcommand, which writes a
+// manifest of all the GraphQL operations made by the given packages (as found
+// by linters.GraphQLAnalyzer), for use in safelisting them.
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/StevenACoffman/fixer/linters"
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// graphqlOp is a single entry in the manifest written by graphql-ops.
+type graphqlOp struct {
+	// OpName is the operation-name, or "" if we couldn't determine it.
+	OpName string `json:"opName"`
+	// Package is the import-path of the package that makes the operation.
+	Package string `json:"package"`
+	// Position is the file:line of the call, relative to the working
+	// directory if possible.
+	Position string `json:"position"`
+	// Function is the function called to make the operation, as named by
+	// lintutil.NameOf.
+	Function string `json:"function"`
+	// Error is set if the operation couldn't be fully analyzed; see
+	// linters.GraphQLOperation.Error.
+	Error string `json:"error,omitempty"`
+	// Document is the full text of the operation-document, if known.
+	Document string `json:"document,omitempty"`
+	// DocumentError is set if the opname is known but the document isn't;
+	// see linters.GraphQLOperation.DocumentError.
+	DocumentError string `json:"documentError,omitempty"`
+}
+
+// runGraphQLOps runs the graphql-ops subcommand with the given arguments (not
+// including the subcommand name).
+func runGraphQLOps(args []string) error {
+	flags := flag.NewFlagSet("graphql-ops", flag.ExitOnError)
+	output := flags.String("o", "", "write the manifest to this file (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(),
+			"usage: fixer graphql-ops [-o manifest.json] [packages]\n\n"+
+				"Writes a JSON manifest of the GraphQL operations made by the\n"+
+				"given packages (default ./...).\n\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	ops, err := findGraphQLOps(patterns)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(ops)
+}
+
+// findGraphQLOps loads the packages matching the given patterns, and returns
+// the GraphQL operations in them, in a deterministic order.
+func findGraphQLOps(patterns []string) ([]graphqlOp, error) {
+	// GraphQLAnalyzer uses facts about the packages it imports, so the
+	// checker needs their syntax too.
+	config := &packages.Config{Mode: packages.LoadAllSyntax}
+	pkgs, err := packages.Load(config, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("errors loading packages")
+	}
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{linters.GraphQLAnalyzer}, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, _ := os.Getwd()
+	ops := []graphqlOp{} // (so we write [] rather than null if there are none)
+	for _, action := range graph.Roots {
+		pkg := action.Package
+		if action.Err != nil {
+			return nil, fmt.Errorf("%v: %w", pkg.PkgPath, action.Err)
+		}
+
+		pkgOps, _ := action.Result.([]linters.GraphQLOperation)
+		for _, op := range pkgOps {
+			position := pkg.Fset.Position(op.Call.Pos())
+			filename := position.Filename
+			if rel, err := filepath.Rel(cwd, filename); err == nil {
+				filename = rel
+			}
+			ops = append(ops, graphqlOp{
+				OpName:        op.OpName,
+				Package:       pkg.PkgPath,
+				Position:      fmt.Sprintf("%v:%v", filename, position.Line),
+				Function:      lintutil.NameOf(lintutil.ObjectFor(op.Call.Fun, pkg.TypesInfo)),
+				Error:         op.Error,
+				Document:      op.Document,
+				DocumentError: op.DocumentError,
+			})
+		}
+	}
+
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].OpName != ops[j].OpName {
+			return ops[i].OpName < ops[j].OpName
+		}
+
+		return ops[i].Position < ops[j].Position
+	})
+
+	return ops, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGraphQLOps runs graphql-ops on the module in testdata/graphqlops, and
+// compares its output to the manifest.json there.
+func TestGraphQLOps(t *testing.T) {
+	dir, err := filepath.Abs(filepath.Join("testdata", "graphqlops"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Positions in the manifest are relative to the working directory.
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+
+	output := filepath.Join(t.TempDir(), "manifest.json")
+	if err := runGraphQLOps([]string{"-o", output, "./..."}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got manifest:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -21,10 +21,13 @@ package linters
 // function getting all the local variables and make sure the first
 // argument in errors.Is and error.As is one of them. Once that is done the
 // 2nd check is pretty simple: make sure the 2nd argument to errors.Is is
-// not a local variable and that errors.As is taking the reference to a
-// variable. If all of these checks pass, this linter is a happy camper.
+// not a local variable, and that the 2nd argument to errors.As has a type
+// errors.As can actually assign to (the same check errors.As does at
+// runtime). If all of these checks pass, this linter is a happy camper.
 import (
+	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
@@ -32,12 +35,74 @@ import (
 	"github.com/StevenACoffman/fixer/lintutil"
 )
 
-// ErrorArgumentAnalyzer runs lint checks that we have the desired
-// documentation.
+// ErrorArgumentAnalyzer checks that the arguments to errors.Is and errors.As
+// are passed in the right order and with the right types.
+//
+// Each check is a separately named rule; every diagnostic carries the rule's
+// name as its Category, and each rule can be turned off with a flag of the
+// same name (e.g. -errorsarguments.is-order=false).
 var ErrorArgumentAnalyzer = &analysis.Analyzer{
 	Name: "errorsarguments",
-	Doc:  "ensures all files and packages are documented",
-	Run:  _runErrorsArgument,
+	Doc: `checks the arguments to errors.Is and errors.As
+
+This analyzer runs the following rules, each of which may be disabled by
+setting the flag of the same name to false:
+
+  local-error: the first argument to errors.Is and errors.As must be a
+    local variable (the error you received).
+  is-order: the second argument to errors.Is must not be a local variable
+    (it should be the sentinel you're comparing against).
+  as-target: the second argument to errors.As must be a non-nil pointer to
+    a type implementing error, or to an interface type.`,
+	Run: _runErrorsArgument,
+}
+
+// Rule names for ErrorArgumentAnalyzer.  These are used both as the
+// Category of the diagnostics each rule reports and as the names of the
+// flags that toggle them.
+const (
+	_localErrorRule = "local-error"
+	_isOrderRule    = "is-order"
+	_asTargetRule   = "as-target"
+)
+
+// _errorArgumentRules says which rules are enabled; it's populated by the
+// analyzer's flags.
+var _errorArgumentRules = map[string]*bool{
+	_localErrorRule: new(bool),
+	_isOrderRule:    new(bool),
+	_asTargetRule:   new(bool),
+}
+
+func init() {
+	ErrorArgumentAnalyzer.Flags.BoolVar(_errorArgumentRules[_localErrorRule],
+		_localErrorRule, true,
+		"check that the first argument to errors.Is/As is a local variable")
+	ErrorArgumentAnalyzer.Flags.BoolVar(_errorArgumentRules[_isOrderRule],
+		_isOrderRule, true,
+		"check that the second argument to errors.Is is not a local variable")
+	ErrorArgumentAnalyzer.Flags.BoolVar(_errorArgumentRules[_asTargetRule],
+		_asTargetRule, true,
+		"check that the second argument to errors.As is a valid target")
+}
+
+// _reportErrorArgument reports a diagnostic for the given rule, unless that
+// rule has been disabled.
+func _reportErrorArgument(
+	pass *analysis.Pass,
+	rule string,
+	pos token.Pos,
+	format string,
+	args ...interface{},
+) {
+	if !*_errorArgumentRules[rule] {
+		return
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:      pos,
+		Category: rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
 }
 
 func _importsKaErrors(file *ast.File) bool {
@@ -124,6 +189,100 @@ func _expressionIsLocalVariable(
 	return false
 }
 
+// _errorType is the predeclared error interface.
+var _errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// _checkErrorsAsTarget reports if the given second argument to errors.As is
+// not something errors.As can assign to.  This mirrors the check errors.As
+// itself does at runtime: the target must be a non-nil pointer, and the type
+// it points to must either implement error or be an interface type.  We also
+// complain about targets of type *error, since any error can be assigned to
+// one and so errors.As will always return true.
+func _checkErrorsAsTarget(pass *analysis.Pass, target ast.Expr) {
+	tv, ok := pass.TypesInfo.Types[target]
+	if !ok {
+		return
+	}
+	typeString := func(typ types.Type) string {
+		return types.TypeString(typ, types.RelativeTo(pass.Pkg))
+	}
+	if tv.IsNil() {
+		_reportErrorArgument(
+			pass, _asTargetRule, target.Pos(),
+			"The second argument of errors.As must be a non-nil pointer",
+		)
+
+		return
+	}
+
+	ptr, ok := tv.Type.Underlying().(*types.Pointer)
+	if !ok {
+		// If the target is itself an interface (typically interface{}),
+		// we can't know statically what's in it; errors.As will check at
+		// runtime.
+		if _, isInterface := tv.Type.Underlying().(*types.Interface); isInterface {
+			return
+		}
+		_reportErrorArgument(
+			pass, _asTargetRule, target.Pos(),
+			"The second argument of errors.As must be a pointer, not %v",
+			typeString(tv.Type),
+		)
+
+		return
+	}
+
+	elem := ptr.Elem()
+	if types.Identical(elem.Underlying(), _errorType) {
+		_reportErrorArgument(
+			pass, _asTargetRule, target.Pos(),
+			"The second argument of errors.As must not be a pointer to error: "+
+				"every error matches, so errors.As will always return true",
+		)
+
+		return
+	}
+	if _, isInterface := elem.Underlying().(*types.Interface); isInterface {
+		return
+	}
+	if types.Implements(elem, _errorType) {
+		return
+	}
+
+	// A common mistake is to take the address of something that's already
+	// a pointer-to-error, or to pass the pointer-to-error itself where a
+	// pointer to it was needed; give a more specific message in those cases.
+	if inner, isPointer := elem.Underlying().(*types.Pointer); isPointer &&
+		types.Implements(inner.Elem(), _errorType) {
+		_reportErrorArgument(
+			pass, _asTargetRule, target.Pos(),
+			"The second argument of errors.As is a pointer to a pointer: "+
+				"%v does not implement error, but %v does",
+			typeString(elem), typeString(inner.Elem()),
+		)
+
+		return
+	}
+	// The other common mistake is the reverse: the error type has pointer
+	// receivers, but we took the address of a non-pointer variable.
+	if types.Implements(types.NewPointer(elem), _errorType) {
+		_reportErrorArgument(
+			pass, _asTargetRule, target.Pos(),
+			"The second argument of errors.As must point to an error, but "+
+				"only *%v (not %v) implements error",
+			typeString(elem), typeString(elem),
+		)
+
+		return
+	}
+	_reportErrorArgument(
+		pass, _asTargetRule, target.Pos(),
+		"The second argument of errors.As must point to a type implementing "+
+			"error or to an interface type, but %v does not implement error",
+		typeString(elem),
+	)
+}
+
 func _inspectFunction(pass *analysis.Pass, node ast.Node) {
 	localVariables := make([]types.Object, 0)
 	// Step 1: Scrape all the local errors out of the function
@@ -178,15 +337,15 @@ func _inspectFunction(pass *analysis.Pass, node ast.Node) {
 		case "github.com/Khan/webapp/pkg/lib/errors.Is", "errors.Is":
 			{
 				if !_expressionIsLocalVariable(pass, err, localVariables) {
-					pass.Reportf(
-						err.Pos(),
+					_reportErrorArgument(
+						pass, _localErrorRule, err.Pos(),
 						"First argument to errors.Is needs to be a local variable",
 					)
 				}
 
 				if _expressionIsLocalVariable(pass, target, localVariables) {
-					pass.Reportf(
-						target.Pos(),
+					_reportErrorArgument(
+						pass, _isOrderRule, target.Pos(),
 						"Second argument to errors.Is cannot be a local variable",
 					)
 				}
@@ -194,23 +353,15 @@ func _inspectFunction(pass *analysis.Pass, node ast.Node) {
 		case "github.com/Khan/webapp/pkg/lib/errors.As", "errors.As":
 			{
 				if !_expressionIsLocalVariable(pass, err, localVariables) {
-					pass.Reportf(
-						err.Pos(),
+					_reportErrorArgument(
+						pass, _localErrorRule, err.Pos(),
 						"First argument to errors.As needs to be a local variable",
 					)
 				}
-				// error.As is a bit different. The second argument is only
-				// allowed to be a reference to a variable, we don't care
-				// about scope.
-				// TODO (jeremygervais): We're just checking if we are taking
-				// the ref of something here. Not that the something is a
-				// pointer already or if it's even an error. We can do better!
-				if _, ok := target.(*ast.UnaryExpr); !ok {
-					pass.Reportf(
-						callExpr.Args[1].Pos(),
-						"The second argument of errors.As must take the reference to an object",
-					)
-				}
+				// error.As is a bit different. The second argument must be a
+				// non-nil pointer to something an error can be assigned
+				// to; we don't care about scope.
+				_checkErrorsAsTarget(pass, target)
 			}
 		}
 
@@ -0,0 +1,26 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestErrorArgumentAsTarget(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.ErrorArgumentAnalyzer, "errorsas")
+}
+
+func TestErrorArgumentRules(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.ErrorArgumentAnalyzer, "errorsrules")
+}
+
+func TestErrorArgumentRulesDisabled(t *testing.T) {
+	setFlag(t, linters.ErrorArgumentAnalyzer, "is-order", "false")
+	setFlag(t, linters.ErrorArgumentAnalyzer, "as-target", "false")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.ErrorArgumentAnalyzer, "errorsrulesoff")
+}
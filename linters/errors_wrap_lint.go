@@ -4,65 +4,265 @@ package linters
 // that the Wrap() call, which is sadly untyped, follows the type rules
 // we need:
 //    errors.KhanWrap(error obj, string, anything, string, anything, ...)
+//
+// Beyond the types, it also checks that the keys are distinct, that they
+// match the configured naming-convention (if any), and that no value is
+// itself an error: the error being wrapped belongs in the first position.
+//
+// The same key/value rules apply to lots of other APIs (logger With(...)
+// calls, metrics tags, and so on), so any function may opt in to them with a
+// directive; see ErrorsWrapAnalyzer for details.
 
 import (
+	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
 
 	"github.com/StevenACoffman/fixer/lintutil"
 )
 
+// ErrorsWrapAnalyzer verifies the arguments to errors.KhanWrap() and to other
+// functions that take alternating keys and values as varargs.
+//
+// To opt a function in to these checks, add a comment
+//
+//	//fixer:kvargs start=1
+//
+// to its doc-comment, where start is the index of the first key (so for
+// errors.KhanWrap(err, key, value, ...) it's 1); it's required, and must be
+// the index of one of the parameters of the (variadic) function.  A trailing
+// comment, starting with //, may follow it.  Then every call to that
+// function must pass an even number of arguments from start on, and each key
+// must be a string-literal, distinct from the other keys in the call.  Like
+// //ka:permission-check, the directive works across packages, and for
+// functions called via an interface both the interface-method and the
+// implementation should have it.
 var ErrorsWrapAnalyzer = &analysis.Analyzer{
-	Name: "errorswrap",
-	Doc:  "verifies arguments to errors.KhanWrap()",
-	Run:  _runErrorsWrap,
+	Name:      "errorswrap",
+	Doc:       "verifies arguments to errors.KhanWrap() and other key/value APIs",
+	Run:       _runErrorsWrap,
+	FactTypes: []analysis.Fact{new(_kvArgs)},
+}
+
+// Fact exported for a *types.Func when the function takes key/value varargs.
+//
+// See the docs for more about Facts:
+// https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=doc#hdr-Modular_analysis_with_Facts
+type _kvArgs struct {
+	// Start is the index of the argument that is the first key.
+	Start int
+}
+
+// AFact tells go/analysis that this is a valid fact type.
+func (*_kvArgs) AFact() {}
+
+// String makes test-assertions work: we can say
+//
+//	func name(...) { // want name:"_kvArgs\(1\)"
+//
+// to assert that we mark that the given function takes key/value args.
+func (f *_kvArgs) String() string { return fmt.Sprintf("_kvArgs(%d)", f.Start) }
+
+// _errorsWrapName is the name of errors.KhanWrap() for lintutil.NameOf.
+const _errorsWrapName = "github.com/Khan/webapp/pkg/lib/errors.Wrap"
+
+// _knownKVArgsFuncs are functions we treat as having a //fixer:kvargs
+// directive even though they don't, because we can't add one.
+var _knownKVArgsFuncs = map[string]_kvArgs{
+	_errorsWrapName: {Start: 1},
+}
+
+// _kvArgsKeyPattern, if set, is a regexp that every key passed to
+// errors.KhanWrap() or another //fixer:kvargs function must match, for
+// example `^[a-z]+(_[a-z0-9]+)*$` to require snake_case.  It's set via the
+// -errorswrap.key-pattern flag.
+var _kvArgsKeyPattern string
+
+func init() {
+	ErrorsWrapAnalyzer.Flags.StringVar(&_kvArgsKeyPattern, "key-pattern", "",
+		"if set, a regexp that all keys passed to errors.KhanWrap() (or "+
+			"other //fixer:kvargs functions) must match")
+}
+
+// _kvArgsDirective marks a function as taking key/value varargs.
+const _kvArgsDirective = "//fixer:kvargs"
+
+// _parseKVArgsDirective looks for a //fixer:kvargs directive in the given
+// comment-block, which documents the given function, and returns the
+// corresponding fact, if any.  If the directive is invalid, or doesn't fit
+// the function, it reports that and returns ok=false.
+func _parseKVArgsDirective(
+	pass *analysis.Pass,
+	comment *ast.CommentGroup,
+	fnObj types.Object,
+) (*_kvArgs, bool) {
+	if comment == nil {
+		return nil, false
+	}
+	// We look line-by-line: Go 1.15+ will filter directives out of
+	// CommentGroup.Text().
+	for _, line := range comment.List {
+		options, ok := strings.CutPrefix(line.Text, _kvArgsDirective)
+		if !ok || (options != "" && options[0] != ' ' && options[0] != '\t') {
+			continue // e.g. //fixer:kvargsX
+		}
+		// A trailing comment may follow the options.
+		if i := strings.Index(options, "//"); i != -1 {
+			options = options[:i]
+		}
+
+		var fact *_kvArgs
+		for _, option := range strings.Fields(options) {
+			value, ok := strings.CutPrefix(option, "start=")
+			start, err := strconv.Atoi(value)
+			if !ok || err != nil || start < 0 || fact != nil {
+				pass.Reportf(line.Pos(),
+					"invalid //fixer:kvargs option %q: expected start=<index>", option)
+
+				return nil, false
+			}
+			fact = &_kvArgs{Start: start}
+		}
+		if fact == nil {
+			pass.Reportf(line.Pos(), "//fixer:kvargs requires start=<index>")
+
+			return nil, false
+		}
+
+		sig, ok := fnObj.Type().(*types.Signature)
+		switch {
+		case !ok || !sig.Variadic():
+			pass.Reportf(line.Pos(),
+				"//fixer:kvargs function %v is not variadic", fnObj.Name())
+
+			return nil, false
+		case fact.Start >= sig.Params().Len():
+			pass.Reportf(line.Pos(),
+				"//fixer:kvargs start=%v is out of range: %v has %v parameters",
+				fact.Start, fnObj.Name(), sig.Params().Len())
+
+			return nil, false
+		}
+
+		return fact, true
+	}
+
+	return nil, false
+}
+
+// _markKVArgsFuncs finds the functions annotated with fixer:kvargs, and
+// exports them for use in our analyses of this and future packages.
+func _markKVArgsFuncs(pass *analysis.Pass) {
+	_mark := func(name *ast.Ident, doc *ast.CommentGroup) {
+		obj := pass.TypesInfo.ObjectOf(name)
+		if obj == nil {
+			return
+		}
+		if fact, ok := _parseKVArgsDirective(pass, doc, obj); ok {
+			pass.ExportObjectFact(obj, fact)
+		}
+	}
+
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncDecl:
+				// look for top-level functions/receivers
+				_mark(node.Name, node.Doc)
+			case *ast.InterfaceType:
+				// and for interface methods
+				for _, method := range node.Methods.List {
+					if len(method.Names) == 1 {
+						_mark(method.Names[0], method.Doc)
+					}
+				}
+			}
+
+			return true
+		})
+	}
+}
+
+// _kvArgsFuncName returns the name we use for the given function in error
+// messages, e.g. "errors.KhanWrap()" or "Logger.With()".
+func _kvArgsFuncName(fnObj types.Object) string {
+	if lintutil.NameOf(fnObj) == _errorsWrapName {
+		return "errors.KhanWrap()"
+	}
+	if sig, ok := fnObj.Type().(*types.Signature); ok && sig.Recv() != nil {
+		recv := lintutil.UnwrapMaybePointer(sig.Recv().Type())
+		if named, ok := recv.(*types.Named); ok {
+			return named.Obj().Name() + "." + fnObj.Name() + "()"
+		}
+	}
+	if fnObj.Pkg() != nil {
+		return fnObj.Pkg().Name() + "." + fnObj.Name() + "()"
+	}
+
+	return fnObj.Name() + "()"
 }
 
 func _runErrorsWrap(pass *analysis.Pass) (interface{}, error) {
+	var keyRegexp *regexp.Regexp
+	if _kvArgsKeyPattern != "" {
+		var err error
+		keyRegexp, err = regexp.Compile(_kvArgsKeyPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_markKVArgsFuncs(pass)
+
 	for _, file := range pass.Files {
 		ast.Inspect(file, func(node ast.Node) bool {
-			// Look for calls to errors.Wrap
+			// Look for calls to errors.Wrap, or other kvargs functions
 			call, ok := node.(*ast.CallExpr)
 			if !ok {
 				return true // recurse
 			}
 
 			fnObj := lintutil.ObjectFor(call.Fun, pass.TypesInfo)
-			if lintutil.NameOf(fnObj) != "github.com/Khan/webapp/pkg/lib/errors.Wrap" {
+			if fnObj == nil {
+				return true
+			}
+			fact, ok := _knownKVArgsFuncs[lintutil.NameOf(fnObj)]
+			if !ok && !pass.ImportObjectFact(fnObj, &fact) {
 				return true
 			}
+			name := _kvArgsFuncName(fnObj)
 
-			if len(call.Args) == 2 && call.Ellipsis != token.NoPos {
+			if call.Ellipsis != token.NoPos {
 				// We can't really lint if you use a slice of varargs.
 				// TODO(benkraft): Should we just disallow that?
 				return true
 			}
 
-			// Rule #1: there should be an odd number of arguments.
-			if len(call.Args)%2 == 0 {
+			// Rule #1: there should be an even number of arguments after
+			// the first key, which for errors.KhanWrap() means an odd
+			// number overall.
+			if len(call.Args) < fact.Start || (len(call.Args)-fact.Start)%2 != 0 {
+				parity := "an even"
+				if fact.Start%2 == 1 {
+					parity = "an odd"
+				}
 				pass.Reportf(call.Pos(),
-					"errors.KhanWrap() should have an odd number of arguments, not %v",
-					len(call.Args))
+					"%v should have %v number of arguments, not %v",
+					name, parity, len(call.Args))
 
 				return true
 			}
 
-			// Rule #2: each odd arg must be a string literal.
-			for i := 1; i < len(call.Args); i += 2 {
-				argLit, ok := call.Args[i].(*ast.BasicLit)
-				if !ok || argLit.Kind != token.STRING {
-					argType := pass.TypesInfo.TypeOf(call.Args[i])
-					pass.Reportf(
-						call.Args[i].Pos(),
-						"errors.KhanWrap() should use string-literals as keys, but arg %v has type %v",
-						// TODO(csilvers): extract and use _shortTypeName from
-						// pkg/kacontext/linters/interface_lint.go.
-						i,
-						argType.String(),
-					)
-				}
+			_lintKVArgsKeys(pass, call, name, fact.Start, keyRegexp)
+			if lintutil.NameOf(fnObj) == _errorsWrapName {
+				_lintErrorsWrapValues(pass, call)
 			}
 
 			return true
@@ -71,3 +271,100 @@ func _runErrorsWrap(pass *analysis.Pass) (interface{}, error) {
 
 	return nil, nil
 }
+
+// _lintKVArgsKeys checks the keys (every other arg, starting at start) of the
+// given call, which must already be known to have the right number of
+// arguments.
+func _lintKVArgsKeys(
+	pass *analysis.Pass,
+	call *ast.CallExpr,
+	name string,
+	start int,
+	keyRegexp *regexp.Regexp,
+) {
+	seenKeys := map[string]int{}
+	for i := start; i < len(call.Args); i += 2 {
+		arg := call.Args[i]
+
+		// Rule #2: each key must be a string literal.  If it's a string
+		// constant, we can at least offer to inline it.
+		key, isConst := _constantString(pass, arg)
+		argLit, ok := arg.(*ast.BasicLit)
+		if !ok || argLit.Kind != token.STRING {
+			argType := pass.TypesInfo.TypeOf(arg)
+			diagnostic := analysis.Diagnostic{
+				Pos: arg.Pos(),
+				End: arg.End(),
+				Message: fmt.Sprintf(
+					"%v should use string-literals as keys, but arg %v has type %v",
+					name,
+					// TODO(csilvers): extract and use _shortTypeName from
+					// pkg/kacontext/linters/interface_lint.go.
+					i,
+					argType.String(),
+				),
+			}
+			if isConst {
+				diagnostic.SuggestedFixes = []analysis.SuggestedFix{{
+					Message: "Replace the constant with its value",
+					TextEdits: []analysis.TextEdit{{
+						Pos:     arg.Pos(),
+						End:     arg.End(),
+						NewText: []byte(strconv.Quote(key)),
+					}},
+				}}
+			}
+			pass.Report(diagnostic)
+		}
+		if !isConst {
+			continue // nothing more we can check about this key
+		}
+
+		// Rule #3: keys must be distinct.
+		if firstIndex, ok := seenKeys[key]; ok {
+			pass.Reportf(arg.Pos(),
+				"%v has duplicate key %q (args %v and %v)",
+				name, key, firstIndex, i)
+		} else {
+			seenKeys[key] = i
+		}
+
+		// Rule #4: keys must match the naming convention, if any.
+		if keyRegexp != nil && !keyRegexp.MatchString(key) {
+			pass.Reportf(arg.Pos(),
+				"%v key %q does not match the pattern `%v`",
+				name, key, keyRegexp)
+		}
+	}
+}
+
+// _lintErrorsWrapValues checks the values (even args after the first) of the
+// given call to errors.KhanWrap().
+func _lintErrorsWrapValues(pass *analysis.Pass, call *ast.CallExpr) {
+	// Rule #5: values should not be errors; the error belongs in the first
+	// position (and if there are two, one should probably wrap the other).
+	// This is specific to errors.KhanWrap(): other key/value APIs, like
+	// loggers, often do want an error as a value.
+	for i := 2; i < len(call.Args); i += 2 {
+		arg := call.Args[i]
+		tv, ok := pass.TypesInfo.Types[arg]
+		if !ok || tv.IsNil() || !types.Implements(tv.Type, _errorType) {
+			continue
+		}
+		key, _ := _constantString(pass, call.Args[i-1])
+		pass.Reportf(arg.Pos(),
+			"errors.KhanWrap() value for key %q is an error: "+
+				"the error being wrapped should be the first argument", key)
+	}
+}
+
+// _constantString returns the value of the given expression, if it's a
+// constant string.
+func _constantString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+
+	return constant.StringVal(tv.Value), true
+}
@@ -0,0 +1,21 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestErrorsWrapKeys(t *testing.T) {
+	setFlag(t, linters.ErrorsWrapAnalyzer, "key-pattern", `^[a-z_]+$`)
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.ErrorsWrapAnalyzer, "kvwrap")
+}
+
+func TestErrorsWrapKVArgsDirective(t *testing.T) {
+	setFlag(t, linters.ErrorsWrapAnalyzer, "key-pattern", `^[a-z_]+$`)
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.ErrorsWrapAnalyzer, "kvargsdep", "kvargs")
+}
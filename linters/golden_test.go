@@ -0,0 +1,79 @@
+package linters_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/txtar"
+)
+
+// TestGoldensCompile checks that the expected output of each suggested fix,
+// in the testdata's .golden files, type-checks.  RunWithSuggestedFixes only
+// compares the fixed source to the golden, so a fix which breaks the build
+// would otherwise go unnoticed.
+//
+// Plain goldens hold the result of applying all the fixes together; txtar
+// goldens hold the result of each fix (by message) separately.
+func TestGoldensCompile(t *testing.T) {
+	testdata, err := filepath.Abs(analysistest.TestData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldens, err := filepath.Glob(filepath.Join(testdata, "src", "*", "*.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(goldens)
+
+	for _, golden := range goldens {
+		filename := strings.TrimSuffix(golden, ".golden")
+		content, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		variants := map[string][]byte{"": content}
+		if archive := txtar.Parse(content); len(archive.Files) > 0 {
+			variants = map[string][]byte{}
+			for _, section := range archive.Files {
+				variants[section.Name] = section.Data
+			}
+		}
+
+		for message, fixed := range variants {
+			name, _ := filepath.Rel(testdata, golden)
+			if message != "" {
+				name += ": " + message
+			}
+			_checkCompiles(t, testdata, name, filename, fixed)
+		}
+	}
+}
+
+// _checkCompiles type-checks the package containing filename, with the given
+// content in place of the file's own, and reports any errors.
+func _checkCompiles(t *testing.T, testdata, name, filename string, content []byte) {
+	t.Helper()
+	config := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir:     filepath.Dir(filename),
+		Env:     append(os.Environ(), "GOPATH="+testdata, "GO111MODULE=off", "GOPROXY=off"),
+		Overlay: map[string][]byte{filename: content},
+	}
+	pkgs, err := packages.Load(config, ".")
+	if err != nil {
+		t.Errorf("%s: %v", name, err)
+
+		return
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			t.Errorf("%s: %v", name, err)
+		}
+	})
+}
@@ -0,0 +1,308 @@
+package linters
+
+// This file contains logic to reconstruct, from go/types, the GraphQL
+// operation-document that the shurcooL-style gqlclient will send to the
+// server for a given query-struct.
+//
+// The client builds the document at runtime via reflect, from the type of the
+// struct-pointer passed to Query/Mutate (and the types of the values in the
+// variables map).  Static analyses only have go/types-style descriptions of
+// types, so we mirror that reflect-based logic here.  The two must be kept in
+// sync: see query.go and ident/ident.go in github.com/shurcooL/graphql.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _graphqlDocument returns the operation-document for an operation of the
+// given type ("query" or "mutation") and name, whose response is decoded into
+// queryArg (a pointer to a struct) and whose variables are in variablesArg
+// (which may be nil, if there are none).
+func _graphqlDocument(
+	pass *analysis.Pass,
+	operationType string,
+	opName string,
+	queryArg ast.Expr,
+	variablesArg ast.Expr,
+) (string, error) {
+	var buf strings.Builder
+	buf.WriteString(operationType)
+	buf.WriteString(" ")
+	buf.WriteString(opName)
+
+	arguments, err := _graphqlQueryArguments(pass, variablesArg)
+	if err != nil {
+		return "", err
+	}
+	if arguments != "" {
+		buf.WriteString("(")
+		buf.WriteString(arguments)
+		buf.WriteString(")")
+	}
+
+	queryType := pass.TypesInfo.TypeOf(queryArg)
+	if queryType == nil {
+		return "", fmt.Errorf("unable to get type of query argument")
+	}
+	err = _writeGraphQLQuery(&buf, queryType, false, map[types.Type]bool{})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// _graphqlQueryArguments returns the variable-definitions for the given
+// variables-map, e.g. `$id:ID!$first:Int`.
+//
+// The client computes these from the dynamic types of the values in the map,
+// so we can only do so if the map is a literal whose values have concrete
+// (non-interface) types.
+func _graphqlQueryArguments(pass *analysis.Pass, variablesArg ast.Expr) (string, error) {
+	if variablesArg == nil {
+		return "", nil
+	}
+	if tv, ok := pass.TypesInfo.Types[variablesArg]; ok && tv.IsNil() {
+		return "", nil
+	}
+	lit, ok := variablesArg.(*ast.CompositeLit)
+	if !ok {
+		return "", fmt.Errorf("unable to get GraphQL variables: non-literal argument")
+	}
+
+	variableTypes := map[string]types.Type{}
+	keys := make([]string, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return "", fmt.Errorf("unable to get GraphQL variables: non-map argument")
+		}
+		key, ok := _constantString(pass, kv.Key)
+		if !ok {
+			return "", fmt.Errorf("unable to get GraphQL variables: non-constant key")
+		}
+		valueType := pass.TypesInfo.TypeOf(kv.Value)
+		if valueType == nil || types.IsInterface(valueType) {
+			return "", fmt.Errorf(
+				"unable to get GraphQL variables: type of %v is not known statically", key)
+		}
+		variableTypes[key] = valueType
+		keys = append(keys, key)
+	}
+
+	// Like the client, sort keys for deterministic output.  Also like the
+	// client, we don't use commas: they're insignificant in GraphQL.
+	sort.Strings(keys)
+	var buf strings.Builder
+	for _, key := range keys {
+		buf.WriteString("$")
+		buf.WriteString(key)
+		buf.WriteString(":")
+		_writeGraphQLArgumentType(&buf, variableTypes[key], true)
+	}
+
+	return buf.String(), nil
+}
+
+// _writeGraphQLArgumentType writes the GraphQL type of a variable of the
+// given Go type, e.g. `[Int!]!`.  value is false if the type is nullable
+// (i.e. we are inside a pointer).
+func _writeGraphQLArgumentType(buf *strings.Builder, typ types.Type, value bool) {
+	if pointer, ok := typ.Underlying().(*types.Pointer); ok {
+		// Pointer is an indicator that this is a nullable value.
+		_writeGraphQLArgumentType(buf, pointer.Elem(), false)
+
+		return
+	}
+
+	switch underlying := typ.Underlying().(type) {
+	case *types.Slice:
+		buf.WriteString("[")
+		_writeGraphQLArgumentType(buf, underlying.Elem(), true)
+		buf.WriteString("]")
+	case *types.Array:
+		buf.WriteString("[")
+		_writeGraphQLArgumentType(buf, underlying.Elem(), true)
+		buf.WriteString("]")
+	default:
+		// Named type, e.g. "Int".  Like reflect.Type.Name(), this is the
+		// name of the Go type, without its package.
+		var name string
+		switch typ := typ.(type) {
+		case *types.Named:
+			name = typ.Obj().Name()
+		case *types.Basic:
+			name = typ.Name()
+		}
+		if name == "string" {
+			// The client maps string to ID; see
+			// https://github.com/shurcooL/githubv4/issues/12.
+			name = "ID"
+		}
+		buf.WriteString(name)
+	}
+
+	if value {
+		// Value is a required type, so add "!" to the end.
+		buf.WriteString("!")
+	}
+}
+
+// _writeGraphQLQuery writes the selection-set for the given Go type, e.g.
+// `{user(id: $id){name,email}}`.  If inline is true, the braces are omitted
+// (because the type is an embedded struct).
+//
+// seen holds the struct types we're in the middle of writing, to avoid
+// infinite recursion (the client itself would overflow its stack).
+func _writeGraphQLQuery(
+	buf *strings.Builder,
+	typ types.Type,
+	inline bool,
+	seen map[types.Type]bool,
+) error {
+	switch underlying := typ.Underlying().(type) {
+	case *types.Pointer:
+		return _writeGraphQLQuery(buf, underlying.Elem(), false, seen)
+	case *types.Slice:
+		return _writeGraphQLQuery(buf, underlying.Elem(), false, seen)
+	case *types.Struct:
+		// If the type implements json.Unmarshaler, it's a scalar, so we
+		// don't expand it.
+		if _hasUnmarshalJSON(typ) {
+			return nil
+		}
+		if seen[typ] {
+			return fmt.Errorf("unable to get GraphQL document: %v is recursive", typ)
+		}
+		seen[typ] = true
+		defer delete(seen, typ)
+
+		if !inline {
+			buf.WriteString("{")
+		}
+		for i := 0; i < underlying.NumFields(); i++ {
+			if i != 0 {
+				buf.WriteString(",")
+			}
+			field := underlying.Field(i)
+			value, ok := reflect.StructTag(underlying.Tag(i)).Lookup("graphql")
+			inlineField := field.Anonymous() && !ok
+			if !inlineField {
+				if ok {
+					buf.WriteString(value)
+				} else {
+					buf.WriteString(_graphqlFieldName(field.Name()))
+				}
+			}
+			err := _writeGraphQLQuery(buf, field.Type(), inlineField, seen)
+			if err != nil {
+				return err
+			}
+		}
+		if !inline {
+			buf.WriteString("}")
+		}
+	}
+
+	return nil
+}
+
+// _hasUnmarshalJSON returns true if *typ has an UnmarshalJSON method.
+func _hasUnmarshalJSON(typ types.Type) bool {
+	methods := types.NewMethodSet(types.NewPointer(typ))
+	for i := 0; i < methods.Len(); i++ {
+		if methods.At(i).Obj().Name() == "UnmarshalJSON" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _graphqlFieldName returns the GraphQL field-name the client uses for a
+// Go field without a graphql tag: the name, converted to lowerCamelCase,
+// treating initialisms as words (so "DatabaseID" becomes "databaseId").
+func _graphqlFieldName(name string) string {
+	words := _parseMixedCaps(name)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+
+			continue
+		}
+		words[i] = strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
+	}
+
+	return strings.Join(words, "")
+}
+
+// _parseMixedCaps splits a MixedCaps name into words, e.g. "ClientMutationID"
+// into "Client", "Mutation", "ID".
+func _parseMixedCaps(name string) []string {
+	var words []string
+	// Split name at any lower -> Upper or Upper -> Upper,lower transitions.
+	runes := []rune(name)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		endOfWord := false
+		switch {
+		case i+1 == len(runes):
+			endOfWord = true
+		case unicode.IsLower(runes[i]) && unicode.IsUpper(runes[i+1]):
+			// lower -> Upper.
+			endOfWord = true
+		case i+2 < len(runes) && unicode.IsUpper(runes[i]) &&
+			unicode.IsUpper(runes[i+1]) && unicode.IsLower(runes[i+2]):
+			// Upper -> Upper,lower: the end of an acronym, followed by a
+			// word.  As a special case, "IDs" is the plural of ID.
+			endOfWord = string(runes[i:i+3]) != "IDs"
+		}
+		if !endOfWord {
+			continue
+		}
+
+		word := string(runes[start : i+1])
+		if first, second, ok := _splitTwoInitialisms(word); ok {
+			words = append(words, first, second)
+		} else {
+			words = append(words, word)
+		}
+		start = i + 1
+	}
+
+	return words
+}
+
+// _splitTwoInitialisms returns the two initialisms that make up the given
+// word, if it's two initialisms run together, like "HTMLURL".
+func _splitTwoInitialisms(word string) (string, string, bool) {
+	word = strings.ToUpper(word)
+	for i := 2; i <= len(word)-2; i++ {
+		if _graphqlInitialisms[word[:i]] && _graphqlInitialisms[word[i:]] {
+			return word[:i], word[i:], true
+		}
+	}
+
+	return "", "", false
+}
+
+// _graphqlInitialisms is the list of initialisms the client knows about,
+// which is in turn that of golint.
+var _graphqlInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true,
+	"QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true,
+	"XMPP": true, "XSRF": true, "XSS": true,
+}
@@ -0,0 +1,136 @@
+package linters
+
+// This file contains the part of GraphQLAnalyzer that finds operations made
+// via genqlient.
+//
+// Genqlient generates a function for each operation, along with a constant
+// holding the operation-document:
+//
+//	// The query or mutation executed by GetUser.
+//	const GetUser_Operation = `
+//	query GetUser ($id: ID!) { ... }
+//	`
+//
+//	func GetUser(
+//		ctx context.Context,
+//		client graphql.Client,
+//		id string,
+//	) (*GetUserResponse, error) { ... }
+//
+// When we analyze the generated package, we find such pairs, and export a
+// fact on the function with the operation it makes; when we analyze a package
+// that calls the function, we report the call as an operation.
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// Fact exported for a *types.Func when the function is a genqlient-generated
+// operation-function.
+//
+// See the docs for more about Facts:
+// https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=doc#hdr-Modular_analysis_with_Facts
+type _genqlientOperation struct {
+	// OpName is the operation-name of the operation.
+	OpName string
+	// Document is the operation-document, exactly as genqlient sends it.
+	Document string
+}
+
+// AFact tells go/analysis that this is a valid fact type.
+func (*_genqlientOperation) AFact() {}
+
+// String makes test-assertions work: we can say
+//
+//	func GetUser(...) { // want GetUser:"_genqlientOperation\(GetUser\)"
+//
+// to assert that we mark that the given function makes an operation.
+func (f *_genqlientOperation) String() string {
+	return "_genqlientOperation(" + f.OpName + ")"
+}
+
+// _genqlientOperationComment is the prefix of the doc-comment genqlient puts
+// on the constant holding each operation-document.
+const _genqlientOperationComment = "The query or mutation executed by "
+
+// _genqlientOpNameRegexp extracts the operation-name from a document.
+var _genqlientOpNameRegexp = regexp.MustCompile(
+	`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// _isGenqlientOperationFunc returns true if the given function has the shape
+// of a genqlient-generated operation-function: it takes a graphql.Client, and
+// returns a response and an error.
+func _isGenqlientOperationFunc(fn *types.Func) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() != nil || sig.Results().Len() != 2 ||
+		!types.Identical(sig.Results().At(1).Type(), types.Universe.Lookup("error").Type()) {
+		return false
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if lintutil.TypeIs(sig.Params().At(i).Type(), "github.com/Khan/genqlient/graphql", "Client") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _markGenqlientOperations finds the genqlient-generated operation-functions
+// in this package, and exports them for use in our analyses of future
+// packages.
+func _markGenqlientOperations(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec, ok := spec.(*ast.ValueSpec)
+				if !ok || len(valueSpec.Names) != 1 {
+					continue
+				}
+				// genqlient puts the comment on the declaration, but if
+				// someone wraps it in a const (...) block it'll be on the
+				// spec.
+				doc := valueSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				if doc == nil || !strings.HasPrefix(doc.Text(), _genqlientOperationComment) {
+					continue
+				}
+				funcName := strings.TrimSuffix(strings.TrimSpace(
+					strings.TrimPrefix(doc.Text(), _genqlientOperationComment)), ".")
+
+				constObj, ok := pass.TypesInfo.Defs[valueSpec.Names[0]].(*types.Const)
+				if !ok || constObj.Val().Kind() != constant.String {
+					continue
+				}
+				fn, ok := pass.Pkg.Scope().Lookup(funcName).(*types.Func)
+				if !ok || !_isGenqlientOperationFunc(fn) {
+					continue
+				}
+
+				document := constant.StringVal(constObj.Val())
+				opName := funcName
+				if match := _genqlientOpNameRegexp.FindStringSubmatch(document); match != nil {
+					opName = match[1]
+				}
+				pass.ExportObjectFact(fn, &_genqlientOperation{
+					OpName:   opName,
+					Document: document,
+				})
+			}
+		}
+	}
+}
@@ -2,7 +2,7 @@ package linters
 
 // This file contains static analysis of GraphQL operations.  It exports two
 // analyzers: one, GraphQLAnalyzer, which analyzes the operations (also used by
-// the tool which extracts their operation-names), and one,
+// `fixer graphql-ops`, which extracts their operation-names), and one,
 // GraphQLLintAnalyzer, which reports errors.
 
 import (
@@ -23,8 +23,11 @@ var GraphQLAnalyzer = &analysis.Analyzer{
 	Run:  _runGraphQL,
 	// This analyzer does not report errors: instead it just returns
 	// information about each operation.  The below GraphQLLintAnalyzer reports
-	// errors; the extract-go-graphql tool exports other data.
+	// errors; `fixer graphql-ops` exports other data.
 	ResultType: reflect.TypeOf([]GraphQLOperation(nil)),
+	// Genqlient-generated operation-functions are marked with a fact; see
+	// graphql_genqlient.go.
+	FactTypes: []analysis.Fact{new(_genqlientOperation)},
 }
 
 // GraphQLOperation represents a GraphQL operation in the source code.
@@ -32,36 +35,93 @@ type GraphQLOperation struct {
 	// Call the ast-node representing the function-call that makes this
 	// operation.
 	Call *ast.CallExpr
-	// Error is a string, set if this operation could not be fully analyzed
-	// (perhaps because it's wrong, or perhaps just because it's a bit too
-	// dynamic for our static-analysis).
+	// Error is a string, set if we could not get the operation-name of this
+	// operation (perhaps because it's wrong, or perhaps just because it's a
+	// bit too dynamic for our static-analysis).
 	Error string
 	// OpName is the operation-name of the operation.
 	OpName string
+	// Document is the full text of the operation-document that will be sent
+	// to the server, as generated by the GraphQL client.  It's set only if
+	// OpName is, and may be "" (with DocumentError set) if the query-type or
+	// variables are too dynamic for us to reconstruct it.
+	Document string
+	// DocumentError is a string, set if OpName is but we could not
+	// reconstruct Document.  This is not itself a problem: the operation may
+	// be perfectly valid, just too dynamic for us.
+	DocumentError string
 }
 
 func (op GraphQLOperation) String() string {
 	if op.Error != "" {
 		return "error: " + op.Error
 	}
+	if op.Document != "" {
+		return "opname: " + op.OpName + ", document: " + op.Document
+	}
+	if op.DocumentError != "" {
+		return "opname: " + op.OpName + ", document error: " + op.DocumentError
+	}
 
 	return "opname: " + op.OpName
 }
 
+// _graphqlFunction describes a function that makes a GraphQL operation.
+type _graphqlFunction struct {
+	// operationType is the type of operation it makes, "query" or
+	// "mutation".
+	operationType string
+	// queryIndex is the index of the argument with the pointer to the
+	// struct the response is decoded into, from which the query is built.
+	queryIndex int
+	// opNameIndex is the index of the argument that has the opname.
+	opNameIndex int
+	// variablesIndex is the index of the argument with the variables map.
+	variablesIndex int
+}
+
 // Names of functions that make a GraphQL operation (names as defined by
-// lintutil.NameOf), mapped to the index of the argument that has the opname.
+// lintutil.NameOf), mapped to a description of their arguments.
 //
 // These are also used by banned_symbol_lint.go.
-var graphqlFunctions = map[string]int{
-	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).Query":              2,
-	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).ServiceAdminQuery":  2,
-	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).Mutate":             2,
-	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).ServiceAdminMutate": 2,
+var graphqlFunctions = map[string]_graphqlFunction{
+	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).Query":              {"query", 1, 2, 3},
+	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).ServiceAdminQuery":  {"query", 1, 2, 3},
+	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).Mutate":             {"mutation", 1, 2, 3},
+	"(github.com/Khan/webapp/pkg/web/gqlclient.Client).ServiceAdminMutate": {"mutation", 1, 2, 3},
+}
+
+// _graphqlOperationWithDocument returns the GraphQLOperation for the given
+// call, with its operation-document if we can construct it.
+func _graphqlOperationWithDocument(
+	pass *analysis.Pass,
+	call *ast.CallExpr,
+	function _graphqlFunction,
+	opName string,
+) GraphQLOperation {
+	// (We know we have at least the query argument, since it comes before
+	// the opname.)
+	op := GraphQLOperation{Call: call, OpName: opName}
+	var variablesArg ast.Expr
+	if function.variablesIndex < len(call.Args) {
+		variablesArg = call.Args[function.variablesIndex]
+	}
+	document, err := _graphqlDocument(pass, function.operationType, opName,
+		call.Args[function.queryIndex], variablesArg)
+	if err != nil {
+		op.DocumentError = err.Error()
+	} else {
+		op.Document = document
+	}
+
+	return op
 }
 
 func _runGraphQL(pass *analysis.Pass) (interface{}, error) {
 	var retval []GraphQLOperation
 
+	_markGenqlientOperations(pass)
+
 	for _, file := range pass.Files {
 		// We don't care about operations in tests.
 		filename := pass.Fset.File(file.Pos()).Name()
@@ -76,14 +136,27 @@ func _runGraphQL(pass *analysis.Pass) (interface{}, error) {
 				return true // recurse
 			}
 
-			// ... to one of the functions we're interested in
+			// ... to one of the functions we're interested in: either a
+			// genqlient-generated function...
 			fnObj := lintutil.ObjectFor(call.Fun, pass.TypesInfo)
-			argIndex, ok := graphqlFunctions[lintutil.NameOf(fnObj)]
+			var genqlientOp _genqlientOperation
+			if fnObj != nil && pass.ImportObjectFact(fnObj, &genqlientOp) {
+				retval = append(retval, GraphQLOperation{
+					Call:     call,
+					OpName:   genqlientOp.OpName,
+					Document: genqlientOp.Document,
+				})
+
+				return true
+			}
+
+			// ... or a method of the (deprecated) gqlclient.
+			function, ok := graphqlFunctions[lintutil.NameOf(fnObj)]
 			if !ok {
 				return true
 			}
 
-			if argIndex >= len(call.Args) {
+			if function.opNameIndex >= len(call.Args) {
 				retval = append(retval, GraphQLOperation{
 					Call: call,
 					Error: fmt.Sprintf(
@@ -95,7 +168,7 @@ func _runGraphQL(pass *analysis.Pass) (interface{}, error) {
 			}
 
 			// Report the opname.
-			opNameArg := call.Args[argIndex]
+			opNameArg := call.Args[function.opNameIndex]
 			opNameValue := pass.TypesInfo.Types[opNameArg].Value
 			switch {
 			case opNameValue == nil:
@@ -109,21 +182,10 @@ func _runGraphQL(pass *analysis.Pass) (interface{}, error) {
 					Error: "unable to get GraphQL opname: non-string argument",
 				})
 			default:
-				retval = append(retval, GraphQLOperation{
-					Call:   call,
-					OpName: constant.StringVal(opNameValue),
-				})
+				retval = append(retval, _graphqlOperationWithDocument(
+					pass, call, function, constant.StringVal(opNameValue)))
 			}
 
-			// TODO(benkraft): Also extract the full text of the
-			// operation-document we will send to the server.  This is somewhat
-			// difficult because we need to use the GraphQL client to generate
-			// it from the given type.  (And that's further complicated by the
-			// fact that static analyses have access to go/types-style
-			// descriptions of types, whereas the GraphQL client wants a
-			// reflect-style description; those are two parallel but separate
-			// worlds!)
-
 			return true
 		})
 	}
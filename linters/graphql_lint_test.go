@@ -0,0 +1,26 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestGraphQLDocument(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.GraphQLTestAnalyzer, "graphqldoc")
+}
+
+func TestGraphQLLint(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.GraphQLLintAnalyzer, "graphqllint")
+}
+
+func TestGraphQLGenqlient(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.GraphQLAnalyzer, "genqlientgen")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.GraphQLTestAnalyzer, "genqlientuse")
+}
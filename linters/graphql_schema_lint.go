@@ -0,0 +1,220 @@
+package linters
+
+// This file contains GraphQLSchemaAnalyzer, which validates the GraphQL
+// operations found by GraphQLAnalyzer against a local copy of the schema, so
+// that we find broken cross-service queries at lint-time rather than in
+// staging.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	gqlast "github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	"golang.org/x/tools/go/analysis"
+)
+
+// GraphQLSchemaAnalyzer validates GraphQL operations against a schema.
+//
+// The schema is read from the .graphql (or .graphqls) SDL files at the paths
+// given by the -graphql_schema.schema flag, a comma-separated list of files
+// or directories (which are searched recursively); if they can't be loaded,
+// setting the flag fails.  Each operation whose
+// document GraphQLAnalyzer could reconstruct is checked for unknown fields,
+// arguments of the wrong type, and the other rules of the GraphQL spec, as
+// well as for uses of @deprecated fields.  Errors are reported at the call
+// that makes the operation.
+//
+// Regardless of the schema, it also reports operation-names that are used
+// more than once in the same package for different documents: the gateway
+// identifies operations by name, so they must be unique.
+var GraphQLSchemaAnalyzer = &analysis.Analyzer{
+	Name:     "graphql_schema",
+	Doc:      "validates GraphQL operations against the schema",
+	Run:      _runGraphQLSchema,
+	Requires: []*analysis.Analyzer{GraphQLAnalyzer},
+}
+
+// _graphqlSchema is the value of the -graphql_schema.schema flag.
+var _graphqlSchema _graphqlSchemaFlag
+
+func init() {
+	GraphQLSchemaAnalyzer.Flags.Var(&_graphqlSchema, "schema",
+		"comma-separated list of GraphQL SDL files, or directories of them, "+
+			"to validate operations against")
+}
+
+// _graphqlSchemaFlag is a flag.Value holding the paths given by the
+// -graphql_schema.schema flag, and the schema they define.
+//
+// We load the schema when the flag is set, so that a missing or invalid
+// schema is reported once, as a flag error, and all the passes (which may run
+// concurrently) can share it.
+type _graphqlSchemaFlag struct {
+	paths  string
+	schema *gqlast.Schema
+}
+
+func (f *_graphqlSchemaFlag) String() string { return f.paths }
+
+func (f *_graphqlSchemaFlag) Set(paths string) error {
+	var schema *gqlast.Schema
+	if paths != "" {
+		var err error
+		schema, err = _loadGraphQLSchema(paths)
+		if err != nil {
+			return err
+		}
+	}
+	f.paths, f.schema = paths, schema
+
+	return nil
+}
+
+// _loadGraphQLSchema returns the schema defined by the SDL files at the given
+// paths (as described in GraphQLSchemaAnalyzer).
+func _loadGraphQLSchema(paths string) (*gqlast.Schema, error) {
+	var sources []*gqlast.Source
+	for _, path := range strings.Split(paths, ",") {
+		err := filepath.Walk(path, func(filename string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() ||
+				(filepath.Ext(filename) != ".graphql" && filepath.Ext(filename) != ".graphqls") {
+				return nil
+			}
+			contents, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, &gqlast.Source{Name: filename, Input: string(contents)})
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no GraphQL schema files found in %v", paths)
+	}
+
+	schema, err := gqlparser.LoadSchema(sources...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load GraphQL schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// _validateGraphQLOperation returns the problems with the given operation
+// according to the given schema.
+func _validateGraphQLOperation(schema *gqlast.Schema, op GraphQLOperation) []string {
+	doc, parseErr := parser.ParseQuery(&gqlast.Source{Input: op.Document})
+	if parseErr != nil {
+		return []string{"unable to parse: " + parseErr.Error()}
+	}
+
+	var problems []string
+	for _, err := range validator.Validate(schema, doc) {
+		problems = append(problems, err.Message)
+	}
+	if len(problems) > 0 {
+		return problems // the definitions may not be filled in
+	}
+
+	// Validate has filled in the definition of each field, so we can check
+	// them for deprecation.
+	var walk func(parentType string, selections gqlast.SelectionSet)
+	walk = func(parentType string, selections gqlast.SelectionSet) {
+		for _, selection := range selections {
+			switch selection := selection.(type) {
+			case *gqlast.Field:
+				if selection.Definition == nil {
+					continue
+				}
+				deprecated := selection.Definition.Directives.ForName("deprecated")
+				if deprecated != nil {
+					reason := "no reason given"
+					if arg := deprecated.Arguments.ForName("reason"); arg != nil && arg.Value != nil {
+						reason = arg.Value.Raw
+					}
+					problems = append(problems, fmt.Sprintf(
+						"field %v.%v is deprecated: %v",
+						parentType, selection.Name, reason))
+				}
+				walk(selection.Definition.Type.Name(), selection.SelectionSet)
+			case *gqlast.InlineFragment:
+				typeCondition := selection.TypeCondition
+				if typeCondition == "" {
+					typeCondition = parentType
+				}
+				walk(typeCondition, selection.SelectionSet)
+			case *gqlast.FragmentSpread:
+				if selection.Definition != nil {
+					walk(selection.Definition.TypeCondition, selection.Definition.SelectionSet)
+				}
+			}
+		}
+	}
+	for _, operation := range doc.Operations {
+		rootType := schema.Query
+		switch operation.Operation {
+		case gqlast.Mutation:
+			rootType = schema.Mutation
+		case gqlast.Subscription:
+			rootType = schema.Subscription
+		}
+		if rootType != nil {
+			walk(rootType.Name, operation.SelectionSet)
+		}
+	}
+
+	return problems
+}
+
+func _runGraphQLSchema(pass *analysis.Pass) (interface{}, error) {
+	ops, _ := pass.ResultOf[GraphQLAnalyzer].([]GraphQLOperation)
+
+	// Rule #1: operation-names must be unique (within the package).  It's
+	// fine to make the same operation in several places (as is common with
+	// genqlient), so long as the document is the same.  (If we couldn't get
+	// the document, we can't tell whether it's the same, so we assume so.)
+	firstUses := map[string]GraphQLOperation{}
+	for _, op := range ops {
+		if op.OpName == "" || op.Document == "" {
+			continue
+		}
+		if firstUse, ok := firstUses[op.OpName]; !ok {
+			firstUses[op.OpName] = op
+		} else if op.Document != firstUse.Document {
+			pass.Reportf(op.Call.Pos(),
+				"GraphQL operation-name %v is already used at %v",
+				op.OpName, pass.Fset.Position(firstUse.Call.Pos()))
+		}
+	}
+
+	schema := _graphqlSchema.schema
+	if schema == nil {
+		return nil, nil
+	}
+
+	// Rule #2: operations must be valid according to the schema.  (If we
+	// couldn't get the document, because it's too dynamic, we can't check
+	// it.)
+	for _, op := range ops {
+		if op.Document == "" {
+			continue
+		}
+		for _, problem := range _validateGraphQLOperation(schema, op) {
+			pass.Reportf(op.Call.Pos(), "GraphQL operation %v: %v", op.OpName, problem)
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,33 @@
+package linters_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	testdata := analysistest.TestData()
+	setFlag(t, linters.GraphQLSchemaAnalyzer, "schema",
+		filepath.Join(testdata, "src", "graphqlschema", "schema.graphql"))
+	analysistest.RunWithSuggestedFixes(
+		t, testdata, linters.GraphQLSchemaAnalyzer, "graphqlschema")
+}
+
+func TestGraphQLSchemaMissing(t *testing.T) {
+	flag := linters.GraphQLSchemaAnalyzer.Flags.Lookup("schema")
+	for _, paths := range []string{
+		filepath.Join(analysistest.TestData(), "src", "graphqlschema", "missing.graphql"),
+		filepath.Join(analysistest.TestData(), "src", "graphqlschema", "graphqlschema.go"),
+	} {
+		if err := flag.Value.Set(paths); err == nil {
+			t.Errorf("setting -schema=%v: got no error", paths)
+		}
+		if value := flag.Value.String(); value != "" {
+			t.Errorf("after setting -schema=%v: got %q, want it unchanged", paths, value)
+		}
+	}
+}
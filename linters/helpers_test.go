@@ -0,0 +1,27 @@
+package linters_test
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// setFlag sets the given flag of the analyzer for the duration of the test.
+//
+// We restore the flag's old value directly, rather than via Set, since
+// repeated flags (like -linewrap.exception) append to their value on Set.
+func setFlag(t *testing.T, analyzer *analysis.Analyzer, name, value string) {
+	t.Helper()
+	flag := analyzer.Flags.Lookup(name)
+	if flag == nil {
+		t.Fatalf("%s has no flag %s", analyzer.Name, name)
+	}
+	current := reflect.ValueOf(flag.Value).Elem()
+	old := reflect.New(current.Type()).Elem()
+	old.Set(current)
+	if err := flag.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { current.Set(old) })
+}
@@ -0,0 +1,182 @@
+package linters
+
+// This file contains the facts KAContextInterfaceAnalyzer exports about which
+// parts of its context each function really uses, so that when a function in
+// another package calls it, we can judge the caller by what the callee uses,
+// rather than by the callee's declared parameter type.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// Fact exported for a *types.Func which has context parameters we checked.
+//
+// See the docs for more about Facts:
+// https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=doc#hdr-Modular_analysis_with_Facts
+type _capabilityUsage struct {
+	// Params maps the index of each parameter we checked to the IDs (as in
+	// types.Func.Id) of the methods the function uses: all the methods of
+	// each of its leaf-interfaces (see _leafInterfaces) which it used.
+	//
+	// We track methods, rather than interfaces, because types can't be
+	// exported in a fact, and because the caller's leaf-interfaces may be
+	// different from the callee's.
+	Params map[int][]string
+}
+
+// AFact tells go/analysis that this is a valid fact type.
+func (*_capabilityUsage) AFact() {}
+
+// String makes test-assertions work: we can say
+//
+//	func F(ctx ...) { // want F:"_capabilityUsage\(0: Log\)"
+//
+// to assert that we mark that the given function uses the given methods.
+func (f *_capabilityUsage) String() string {
+	indexes := make([]int, 0, len(f.Params))
+	for i := range f.Params {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	parts := make([]string, len(indexes))
+	for j, i := range indexes {
+		parts[j] = fmt.Sprintf("%v: %v", i, strings.Join(f.Params[i], ", "))
+	}
+
+	return "_capabilityUsage(" + strings.Join(parts, "; ") + ")"
+}
+
+// _hasExplicitMethodID returns true if iface has an explicit method with the
+// given ID (see types.Func.Id).
+func _hasExplicitMethodID(iface *types.Interface, id string) bool {
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		if iface.ExplicitMethod(i).Id() == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _calleeUsage returns the IDs of the methods used by the given call's
+// callee, in the parameter to which its i'th argument is assigned, if we know
+// them (that is, if the callee is in another package, and we checked that
+// parameter there).
+func (tracker *_interfaceTracker) _calleeUsage(
+	call *ast.CallExpr,
+	funcType *types.Signature,
+	i int,
+) ([]string, bool) {
+	fn, ok := lintutil.ObjectFor(call.Fun, tracker.typesInfo).(*types.Func)
+	if !ok || fn.Pkg() == tracker.pkg {
+		return nil, false
+	}
+
+	var usage _capabilityUsage
+	if !tracker.pass.ImportObjectFact(fn, &usage) {
+		return nil, false
+	}
+	if nParams := funcType.Params().Len(); i >= nParams && funcType.Variadic() {
+		i = nParams - 1
+	}
+	methods, ok := usage.Params[i]
+
+	return methods, ok
+}
+
+// _calleeUsageSuffices returns true if the given variable, passed as an
+// argument of the given parameter-type to a function which uses the methods
+// with the given IDs, would still be assignable to that parameter-type if we
+// narrowed it to just the leaf-interfaces (see _leafInterfaces) containing
+// those methods.  If not, the call needs the whole parameter-type, whatever
+// the function does with it.
+func _calleeUsageSuffices(obj types.Object, paramType types.Type, methods []string) bool {
+	paramIface, ok := paramType.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+
+	usage := &_objInfo{obj: obj, calleeUses: map[types.Type][]string{paramType: methods}}
+	var embeds []types.Type
+	for _, leaf := range _leafInterfaces(obj.Type()) {
+		if usage._interfaceWasUsed(leaf) {
+			embeds = append(embeds, leaf)
+		}
+	}
+	narrowed := types.NewInterfaceType(nil, embeds).Complete()
+
+	return types.Implements(narrowed, paramIface)
+}
+
+// exportUsage exports a _capabilityUsage fact for each function in the given
+// files which has parameters we checked.
+func (tracker *_interfaceTracker) exportUsage(files []*ast.File) {
+	for _, funcDecl := range lintutil.FilterFuncs(files, func(*ast.FuncDecl) bool { return true }) {
+		fn, ok := tracker.typesInfo.Defs[funcDecl.Name].(*types.Func)
+		if !ok {
+			continue
+		}
+
+		usage := _capabilityUsage{Params: map[int][]string{}}
+		i := 0
+		for _, field := range funcDecl.Type.Params.List {
+			if len(field.Names) == 0 {
+				i++
+
+				continue
+			}
+			for _, name := range field.Names {
+				methods, ok := tracker._usedMethods(tracker.typesInfo.Defs[name])
+				if ok {
+					usage.Params[i] = methods
+				}
+				i++
+			}
+		}
+
+		if len(usage.Params) > 0 {
+			tracker.pass.ExportObjectFact(fn, &usage)
+		}
+	}
+}
+
+// _usedMethods returns the IDs of the methods of the leaf-interfaces of the
+// given variable which it uses, if we know them.
+//
+// We don't know them if the variable isn't tracked, or if it's the argument
+// to a cached function (which may need the interfaces we don't otherwise see
+// used; see _maybeNeededForCache).
+func (tracker *_interfaceTracker) _usedMethods(obj types.Object) ([]string, bool) {
+	info := tracker.trackedIdents[obj]
+	if obj == nil || info == nil || info.isCached {
+		return nil, false
+	}
+
+	ids := map[string]bool{}
+	for _, leaf := range _leafInterfaces(obj.Type()) {
+		if !info._interfaceWasUsed(leaf) {
+			continue
+		}
+		iface, ok := leaf.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for i := 0; i < iface.NumMethods(); i++ {
+			ids[iface.Method(i).Id()] = true
+		}
+	}
+
+	retval := make([]string, 0, len(ids))
+	for id := range ids {
+		retval = append(retval, id)
+	}
+	sort.Strings(retval)
+
+	return retval, true
+}
@@ -0,0 +1,343 @@
+package linters
+
+// This file contains the suggested fixes for KAContextInterfaceAnalyzer, which
+// rewrite an inline context-interface, such as the type of ctx in
+//	func F(ctx interface {
+//		kacontext.Base
+//		log.KAContext
+//	})
+// to request exactly the interfaces the variable uses.
+//
+// We only fix inline interfaces with no explicit methods: named types may be
+// used elsewhere, and interfaces with explicit methods are a single "leaf"
+// (see _leafInterfaces) so we can't tell which parts are unused.
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _declaredInterfaces returns the inline interface-types of the variables
+// declared in the given files (by the position of the variable's name), for
+// variables which are declared alone (not as in `a, b interface{ ... }`,
+// where changing the type would change the other variable's too).
+func _declaredInterfaces(files []*ast.File) map[token.Pos]*ast.InterfaceType {
+	retval := map[token.Pos]*ast.InterfaceType{}
+	add := func(names []*ast.Ident, typ ast.Expr) {
+		iface, ok := typ.(*ast.InterfaceType)
+		if ok && len(names) == 1 {
+			retval[names[0].Pos()] = iface
+		}
+	}
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FuncType:
+				if node.Params != nil {
+					for _, field := range node.Params.List {
+						add(field.Names, field.Type)
+					}
+				}
+			case *ast.ValueSpec:
+				add(node.Names, node.Type)
+			}
+
+			return true
+		})
+	}
+
+	return retval
+}
+
+// _embeds returns the embedded interfaces of the given inline interface, or
+// nil if it has explicit methods.
+func _embeds(iface *ast.InterfaceType) []*ast.Field {
+	for _, field := range iface.Methods.List {
+		if len(field.Names) > 0 {
+			return nil
+		}
+	}
+
+	return iface.Methods.List
+}
+
+// _containsType returns true if list contains a type identical to typ.
+func _containsType(list []types.Type, typ types.Type) bool {
+	for _, elem := range list {
+		if types.Identical(elem, typ) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _fileContaining returns the file containing the given position.
+func _fileContaining(files []*ast.File, pos token.Pos) *ast.File {
+	for _, file := range files {
+		if file.Pos() <= pos && pos < file.End() {
+			return file
+		}
+	}
+
+	return nil
+}
+
+// _importedName returns the name by which the given import is referred to in
+// its file.
+func _importedName(spec *ast.ImportSpec, typesInfo *types.Info) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	if pkgName, ok := typesInfo.Implicits[spec].(*types.PkgName); ok {
+		return pkgName.Name()
+	}
+
+	return ""
+}
+
+// _typeExprIn returns an expression referring to the given type in the given
+// file, and the path of the package that needs to be imported for it (or ""
+// if none).  It returns ok=false if we can't refer to the type (it's unnamed
+// or unexported, or its package-name is taken).
+func _typeExprIn(
+	typ types.Type,
+	file *ast.File,
+	pkg *types.Package,
+	typesInfo *types.Info,
+) (expr, importPath string, ok bool) {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return "", "", false
+	}
+	obj := named.Obj()
+	if obj.Pkg() == pkg {
+		return obj.Name(), "", true
+	}
+	if !obj.Exported() {
+		return "", "", false
+	}
+
+	name, importPath, ok := _packageNameIn(obj.Pkg(), file, typesInfo)
+	if !ok {
+		return "", "", false
+	}
+
+	return name + "." + obj.Name(), importPath, true
+}
+
+// _packageNameIn returns the name by which the given (other) package may be
+// referred to in the given file, and its path if it needs to be imported (or
+// "" if it's already imported).  It returns ok=false if another import has
+// the name we need.
+func _packageNameIn(
+	target *types.Package,
+	file *ast.File,
+	typesInfo *types.Info,
+) (name, importPath string, ok bool) {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := _importedName(spec, typesInfo)
+		switch {
+		case path == target.Path() && name != "_" && name != ".":
+			return name, "", true
+		case name == target.Name():
+			return "", "", false // another import has the name we need
+		}
+	}
+
+	return target.Name(), target.Path(), true
+}
+
+// _addImportEdit returns an edit which imports the given package in the given
+// file.
+func _addImportEdit(file *ast.File, path string) analysis.TextEdit {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		if genDecl.Lparen.IsValid() {
+			return analysis.TextEdit{
+				Pos:     genDecl.Rparen,
+				End:     genDecl.Rparen,
+				NewText: []byte("\t" + strconv.Quote(path) + "\n"),
+			}
+		}
+
+		return analysis.TextEdit{
+			Pos:     genDecl.End(),
+			End:     genDecl.End(),
+			NewText: []byte("\nimport " + strconv.Quote(path)),
+		}
+	}
+
+	return analysis.TextEdit{
+		Pos:     file.Name.End(),
+		End:     file.Name.End(),
+		NewText: []byte("\n\nimport " + strconv.Quote(path)),
+	}
+}
+
+// _deleteImportEdit returns an edit which removes the given import from its
+// file: the whole import declaration if it's the only import in it, or else
+// just the import's line.  It returns ok=false if the import shares its line
+// with another.
+func _deleteImportEdit(f *_file, spec *ast.ImportSpec) (edit analysis.TextEdit, ok bool) {
+	var node ast.Node = spec
+	for _, decl := range f.AstFile.Decls {
+		genDecl, isGenDecl := decl.(*ast.GenDecl)
+		if !isGenDecl || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, other := range genDecl.Specs {
+			switch {
+			case other == spec && len(genDecl.Specs) == 1:
+				node = genDecl
+			case other != spec && (f.Line(other.Pos()) == f.Line(spec.Pos()) ||
+				f.Line(other.End()) == f.Line(spec.Pos())):
+				return analysis.TextEdit{}, false
+			}
+		}
+	}
+
+	startLine, endLine := f.Line(node.Pos()), f.Line(node.End())
+	// If the import was the first of a group of imports, or alone between
+	// blank lines, we remove the blank line after it too, as gofmt would.
+	if node == spec {
+		prev, errPrev := f.LineText(startLine - 1)
+		next, errNext := f.LineText(endLine + 1)
+		if errPrev == nil && errNext == nil && strings.TrimSpace(next) == "" &&
+			(strings.TrimSpace(prev) == "" || strings.HasSuffix(strings.TrimSpace(prev), "(")) {
+			endLine++
+		}
+	}
+	lineEnd, err := f.LineEnd(endLine)
+	if err != nil {
+		return analysis.TextEdit{}, false
+	}
+
+	return analysis.TextEdit{Pos: f.LineStart(startLine), End: lineEnd + 1}, true
+}
+
+// _minimizeInterfaceFixes returns a fix which rewrites the given inline
+// interface, the type of some variable, to request exactly the interfaces it
+// uses: removing the unused ones, and adding the unrequested ones.
+//
+// If we can't (for example, because we'd need to mention a type which isn't
+// visible in this package), we return no fixes.
+func _minimizeInterfaceFixes(
+	pass *analysis.Pass,
+	iface *ast.InterfaceType,
+	unused, unrequested []types.Type,
+) []analysis.SuggestedFix {
+	file := _fileContaining(pass.Files, iface.Pos())
+	embeds := _embeds(iface)
+	if file == nil || len(embeds) == 0 {
+		return nil
+	}
+	f := &_file{File: pass.Fset.File(file.Pos()), AstFile: file}
+
+	var elems, importPaths []string
+	seen := map[string]bool{}
+	addType := func(typ types.Type) bool {
+		expr, importPath, ok := _typeExprIn(typ, file, pass.Pkg, pass.TypesInfo)
+		if !ok {
+			return false
+		}
+		if !seen[expr] {
+			seen[expr] = true
+			elems = append(elems, expr)
+			if importPath != "" {
+				importPaths = append(importPaths, importPath)
+			}
+		}
+
+		return true
+	}
+
+	// Keep the embeds we use, as written.  If we use only part of an embed,
+	// replace it by the parts we use.
+	for _, embed := range embeds {
+		leaves := _leafInterfaces(pass.TypesInfo.TypeOf(embed.Type))
+		var usedLeaves []types.Type
+		for _, leaf := range leaves {
+			if !_containsType(unused, leaf) {
+				usedLeaves = append(usedLeaves, leaf)
+			}
+		}
+
+		switch len(usedLeaves) {
+		case 0:
+			continue // remove it
+		case len(leaves):
+			text, err := f.Range(embed.Type.Pos(), embed.Type.End())
+			if err != nil {
+				return nil
+			}
+			if !seen[text] {
+				seen[text] = true
+				elems = append(elems, text)
+			}
+		default:
+			for _, leaf := range usedLeaves {
+				if !addType(leaf) {
+					return nil
+				}
+			}
+		}
+	}
+
+	// Add the interfaces we use but didn't request.
+	for _, typ := range unrequested {
+		for _, visible := range _expandUnexportedNames(typ, pass.Pkg) {
+			if !addType(visible) {
+				return nil
+			}
+		}
+	}
+
+	// Write the new interface, on one line if the old one was.
+	var newText string
+	if f.Line(iface.Pos()) == f.Line(iface.End()) {
+		newText = "interface{ " + strings.Join(elems, "; ") + " }"
+	} else {
+		line, err := f.LineText(f.Line(iface.End()))
+		if err != nil {
+			return nil
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		newText = "interface {\n"
+		for _, elem := range elems {
+			newText += indent + "\t" + elem + "\n"
+		}
+		newText += indent + "}"
+	}
+	if len(elems) == 0 {
+		newText = "interface{}"
+	}
+
+	edits := []analysis.TextEdit{{
+		Pos: iface.Pos(), End: iface.End(), NewText: []byte(newText),
+	}}
+	sort.Strings(importPaths)
+	for i, path := range importPaths {
+		if i == 0 || importPaths[i-1] != path {
+			edits = append(edits, _addImportEdit(file, path))
+		}
+	}
+
+	return []analysis.SuggestedFix{{
+		Message:   "Request exactly the interfaces used",
+		TextEdits: edits,
+	}}
+}
@@ -17,6 +17,7 @@ package linters
 //
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
@@ -28,10 +29,15 @@ import (
 	"github.com/StevenACoffman/fixer/lintutil"
 )
 
+// KAContextInterfaceAnalyzer checks kacontext interfaces by default; see
+// interface_pattern.go to check other capability-interfaces instead.
 var KAContextInterfaceAnalyzer = &analysis.Analyzer{
 	Name: "kacontextinterface",
 	Doc:  "enforces that kacontext interfaces aren't unnecessarily large",
 	Run:  _runInterface,
+	// We export which interfaces each function really uses; see
+	// _capabilityUsage.
+	FactTypes: []analysis.Fact{new(_capabilityUsage)},
 }
 
 // _explicitInterfaces returns the KAContext-style interfaces explicitly
@@ -102,20 +108,20 @@ func _explicitInterfaces(typ types.Type, currentPackage *types.Package) []types.
 //	_leafInterfaces(B) => B
 //	_leafInterfaces(C) => C
 //
-// TODO(benkraft): Stopping at interfaces with methods is sort of a heuristic.
-// It doesn't work very well in cases where caller or callee embed their own
-// explicit method, rather than another context.  For example, if caller has
+// Stopping at interfaces with methods is sort of a heuristic.  It doesn't work
+// very well in cases where caller or callee embed their own explicit method,
+// rather than another context.  For example, if caller has
 // `interface { A; B; M() }` and one callee wants A and the other callee wants
-// `interface { B; M() }`, we'll see both as unused: the caller is seen as
-// having a single context-interface `{ A; B; M() }`, which is not equal to
-// either A or `{ B; M() }`.  Luckily, the only place we do this is in pkg with
-// kacontext.Base (e.g. pkg/gcloud/pubsub.LogErrorAsync); callers outside of
-// pkg don't really care (even when calling such a function, since we
-// special-case that if you ask for kacontext.Base and only use
-// context.Context, that's ok).  One way to solve for this would be to have
-// some base interface included in each context, but that would require adding
-// new packages (pkg/kacontext is too high), and doesn't seem to have many
-// benefits other than in this linter.
+// `interface { B; M() }`, the caller is seen as having a single
+// context-interface `{ A; B; M() }`, which is not equal to either A or
+// `{ B; M() }`.  When the callees are in other packages, we avoid this by
+// looking at which methods they really use (see _capabilityUsage): the second
+// uses M, which is explicit in the caller's interface, so it's used.
+//
+// TODO(benkraft): We still see such interfaces as unused when the callees are
+// in the same package.  Luckily, the only place we do this is in pkg with
+// kacontext.Base (e.g. pkg/gcloud/pubsub.LogErrorAsync), and we special-case
+// that if you ask for kacontext.Base and only use context.Context, that's ok.
 func _leafInterfaces(typ types.Type) []types.Type {
 	iface, ok := typ.Underlying().(*types.Interface)
 	if !ok {
@@ -355,33 +361,7 @@ func _hasExplicitMethod(iface *types.Interface, name string) bool {
 // TODO(benkraft): Actually figure out which types this particular cached
 // function needs.  For now, we just allow anything that any cache needs.
 func _maybeNeededForCache(typ types.Type) bool {
-	// used by settingscache
-	return lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/kacontext", "Base") ||
-		// used by datastore, settingscache, and Expiration
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/lib/timectx", "KAContext") ||
-		// used by memorystore and settingscache
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/lib/log", "KAContext") ||
-		// used by memorystore and settingscache
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/gcloud/memorystore", "KAContext") ||
-		// used by datastore and settingscache
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/gcloud/datastore", "KAContext") ||
-		// used by PersistAcrossPublish
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/content", "KAContext") ||
-		// common in key-params-fxns (or perhaps a future cache-option!)
-		//
-		// TODO(benkraft): Having a key-params-fxn ask for a context that
-		// the cached function doesn't use suggests that we are
-		// over-caching.  The problem is that the GraphQL client will
-		// forward these headers even if they aren't in your context.
-		// That's a recipe for caching bugs!
-		//
-		// Instead, we should do something like: have the GraphQL client
-		// ask for ka-locale-context if and only if we are forwarding the
-		// ka-locale header (and so on for other contexts).  It's unclear
-		// if there's a reasonable way to do that without generics, though.
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/web", "CountryContext") ||
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/web", "CurriculumContext") ||
-		lintutil.TypeIs(typ, "github.com/Khan/webapp/pkg/web", "KALocaleContext")
+	return _capabilities.cacheInterfaces.matches(typ)
 }
 
 // _interfaceTracker is the object we use to manage our process of marking
@@ -393,6 +373,7 @@ type _interfaceTracker struct {
 
 	typesInfo *types.Info
 	pkg       *types.Package
+	pass      *analysis.Pass
 }
 
 // track adds the given identifier to have its interface usage tracked.
@@ -401,7 +382,7 @@ type _interfaceTracker struct {
 func (tracker *_interfaceTracker) track(ident *ast.Ident) {
 	obj := tracker.typesInfo.Defs[ident]
 	// obj is only nil in edge cases we don't care about (like struct fields)
-	if obj == nil || obj.Name() == "_" || !isContextType(obj.Type()) {
+	if obj == nil || obj.Name() == "_" || !_capabilities.isCapabilityType(obj.Type()) {
 		return
 	}
 
@@ -410,11 +391,9 @@ func (tracker *_interfaceTracker) track(ident *ast.Ident) {
 		return // this isn't a ctx.
 	}
 
-	// Tests can ask for TestContext, which is useful if you want to Clone(),
-	// or just if you want to make it obvious that you are a test util and
-	// tests should pass in their `suite.KAContext()`.  In any case, we'll
-	// allow it.
-	if lintutil.TypeIs(obj.Type(), kacontextPath, "TestContext") {
+	// Some types, like kacontext.TestContext, are exempt; see
+	// _capabilities.
+	if _capabilities.exempt.matches(obj.Type()) {
 		return
 	}
 
@@ -422,7 +401,7 @@ func (tracker *_interfaceTracker) track(ident *ast.Ident) {
 	// probably to match an interface or for future expansion, and anyway
 	// is a job for an unused-argument linter, not us.  We just skip
 	// checking this case.
-	if len(ifaces) == 1 && lintutil.TypeIs(ifaces[0], "context", "Context") {
+	if len(ifaces) == 1 && _capabilities.roots.matches(ifaces[0]) {
 		return
 	}
 
@@ -431,6 +410,7 @@ func (tracker *_interfaceTracker) track(ident *ast.Ident) {
 		obj:           obj,
 		interfaceUses: map[types.Type]bool{},
 		methodUses:    map[string]bool{},
+		calleeUses:    map[types.Type][]string{},
 	}
 }
 
@@ -454,7 +434,16 @@ func (tracker *_interfaceTracker) _markArgsUsed(call *ast.CallExpr) {
 			continue
 		}
 		info := tracker.trackedIdents[tracker.typesInfo.ObjectOf(argIdent)]
-		if info != nil {
+		if info == nil {
+			continue
+		}
+		// If the function is in another package, we know which methods it
+		// really uses; but the call must still compile, so we can only judge
+		// the variable by those if that leaves it assignable to the parameter.
+		methods, ok := tracker._calleeUsage(call, funcType, i)
+		if ok && _calleeUsageSuffices(info.obj, param.Type(), methods) {
+			info.calleeUses[param.Type()] = append(info.calleeUses[param.Type()], methods...)
+		} else {
 			info.interfaceUses[param.Type()] = true
 		}
 	}
@@ -505,7 +494,7 @@ func (tracker *_interfaceTracker) _markReceiverUsed(call *ast.CallExpr) {
 // in a special hack.
 func (tracker *_interfaceTracker) _markCachedFunctionUsed(call *ast.CallExpr) {
 	funcName := lintutil.NameOf(lintutil.ObjectFor(call.Fun, tracker.typesInfo))
-	if funcName != "github.com/Khan/webapp/pkg/lib/cache.Cache" ||
+	if funcName == "" || funcName != _capabilities.cacheFunc ||
 		len(call.Args) == 0 { // len == 0 never happens (cache arg is required)
 		return
 	}
@@ -530,7 +519,7 @@ func (tracker *_interfaceTracker) _markCachedFunctionUsed(call *ast.CallExpr) {
 // handle other ways, so we just put in a special hack.
 func (tracker *_interfaceTracker) _markKeyParamsFunctionUsed(call *ast.CallExpr) {
 	funcName := lintutil.NameOf(lintutil.ObjectFor(call.Fun, tracker.typesInfo))
-	if funcName != "github.com/Khan/webapp/pkg/lib/cache.KeyParamsFxn" ||
+	if funcName == "" || funcName != _capabilities.keyParamsFunc ||
 		len(call.Args) == 0 { // len == 0 never happens (cache arg is required)
 		return
 	}
@@ -660,7 +649,7 @@ func (tracker *_interfaceTracker) markTracegroupUses(startNode ast.Node) {
 			return true
 		}
 		funcName := lintutil.NameOf(lintutil.ObjectFor(call.Fun, tracker.typesInfo))
-		if funcName != "github.com/Khan/webapp/pkg/external/opentelemetry/tracegroup.WithContext" {
+		if funcName == "" || funcName != _capabilities.groupFunc {
 			return true
 		}
 		addrExpr, ok := call.Args[0].(*ast.UnaryExpr)
@@ -683,8 +672,7 @@ func (tracker *_interfaceTracker) markTracegroupUses(startNode ast.Node) {
 		// Also count this as a use of context.Context on the group-context
 		// (since it is).
 		for _, iface := range _leafInterfaces(ctxInfo.obj.Type()) {
-			if lintutil.TypeIs(iface, "context", "Context") ||
-				lintutil.TypeIs(iface, kacontextPath, "Base") {
+			if _capabilities.roots.matches(iface) || _capabilities.bases.matches(iface) {
 				ctxInfo.interfaceUses[iface] = true
 			}
 		}
@@ -914,6 +902,14 @@ type _objInfo struct {
 	// with the variable as a receiver.  (Specifically it contains the method
 	// names.)
 	methodUses map[string]bool
+	// calleeUses contains the interfaces as which the variable was passed to
+	// functions in other packages, mapped to the IDs of the methods those
+	// functions really use (see _capabilityUsage).  Unlike interfaceUses, only
+	// the methods count as uses; but as with interfaceUses, the variable must
+	// explicitly request the interfaces.  We only add an interface here if
+	// those methods suffice for the call to compile (see
+	// _calleeUsageSuffices); otherwise it goes in interfaceUses.
+	calleeUses map[types.Type][]string
 	// isCached is set if this variable is the argument to a cached function;
 	// see _maybeNeededForCache.
 	isCached bool
@@ -952,11 +948,21 @@ func (info *_objInfo) _interfaceWasUsed(typ types.Type) bool {
 		}
 	}
 
-	// If you requested kacontext.Base, it's okay if you only used
-	// context.Context.
-	if lintutil.TypeIs(typ, kacontextPath, "Base") {
-		context := _embedNamed(typ, "context", "Context")
-		if context != nil && info._interfaceWasUsed(context) {
+	// We passed the variable to a function in another package which (perhaps
+	// indirectly) calls a method defined explicitly in this interface.
+	for _, methodIDs := range info.calleeUses {
+		for _, id := range methodIDs {
+			if _hasExplicitMethodID(iface, id) {
+				return true
+			}
+		}
+	}
+
+	// If you requested kacontext.Base (or another base), it's okay if you
+	// only used context.Context (or the relevant root).
+	if _capabilities.bases.matches(typ) {
+		root := _capabilities.embeddedRoot(typ)
+		if root != nil && info._interfaceWasUsed(root) {
 			return true
 		}
 	}
@@ -996,9 +1002,9 @@ func (info *_objInfo) _interfaceWasRequested(typ types.Type) bool {
 			return true
 		}
 
-		// If we used context.Context, it's ok if we requested kacontext.Base.
-		if lintutil.TypeIs(embed, kacontextPath, "Base") &&
-			lintutil.TypeIs(typ, "context", "Context") {
+		// If we used context.Context, it's ok if we requested kacontext.Base
+		// (or similarly for other roots and bases).
+		if _capabilities.bases.matches(embed) && _capabilities.roots.matches(typ) {
 			return true
 		}
 	}
@@ -1058,7 +1064,14 @@ func (info *_objInfo) problems() (allUnused bool, unused, unrequested []types.Ty
 		}
 	}
 
+	usedInterfaces := make([]types.Type, 0, len(info.interfaceUses)+len(info.calleeUses))
 	for usedInterface := range info.interfaceUses {
+		usedInterfaces = append(usedInterfaces, usedInterface)
+	}
+	for usedInterface := range info.calleeUses {
+		usedInterfaces = append(usedInterfaces, usedInterface)
+	}
+	for _, usedInterface := range usedInterfaces {
 		for _, usedEmbed := range _explicitInterfaces(usedInterface, info.obj.Pkg()) {
 			if !info._interfaceWasRequested(usedEmbed) {
 				unrequested = append(unrequested, usedEmbed)
@@ -1090,6 +1103,7 @@ func _runInterface(pass *analysis.Pass) (interface{}, error) {
 		map[types.Object]*_objInfo{},
 		pass.TypesInfo,
 		pass.Pkg,
+		pass,
 	}
 
 	// First, find the identifiers we want to look at.
@@ -1107,7 +1121,20 @@ func _runInterface(pass *analysis.Pass) (interface{}, error) {
 		tracker.markUses(file)
 	}
 
+	// Export what we found, for packages which call our functions.
+	tracker.exportUsage(pass.Files)
+
 	// Finally, report any errors.
+	//
+	// We only offer fixes for variables which don't share their info with
+	// others (see identifyInterfaceMethods): if we changed the type of one
+	// implementation of an interface method, it would no longer implement the
+	// interface.
+	inlineInterfaces := _declaredInterfaces(pass.Files)
+	infoCounts := map[*_objInfo]int{}
+	for _, info := range tracker.trackedIdents {
+		infoCounts[info]++
+	}
 	for obj, info := range tracker.trackedIdents {
 		filename := pass.Fset.File(obj.Pos()).Name()
 		if strings.HasSuffix(filename, "_test.go") {
@@ -1117,6 +1144,14 @@ func _runInterface(pass *analysis.Pass) (interface{}, error) {
 
 		// Figure out the errors.
 		allUnused, unused, unrequested := info.problems()
+		var iface *ast.InterfaceType
+		if info.obj == obj && infoCounts[info] == 1 {
+			iface = inlineInterfaces[obj.Pos()]
+		}
+		var fixes []analysis.SuggestedFix
+		if iface != nil && !allUnused {
+			fixes = _minimizeInterfaceFixes(pass, iface, unused, unrequested)
+		}
 
 		// Report!
 		switch {
@@ -1132,19 +1167,54 @@ func _runInterface(pass *analysis.Pass) (interface{}, error) {
 			// report unrequested contexts first; they may clarify why a
 			// context is unused (namely you are using some part of it, not the
 			// actual interface).
-			pass.Reportf(obj.Pos(),
-				"%s uses but does not explicitly request interface(s) %s; "+
-					"add it explicitly (see ADR-429)",
-				obj.Name(), _formatTypeList(unrequested, pass.Pkg))
+			pass.Report(analysis.Diagnostic{
+				Pos: obj.Pos(),
+				Message: fmt.Sprintf(
+					"%s uses but does not explicitly request interface(s) %s; "+
+						"add it explicitly (see ADR-429)",
+					obj.Name(), _formatTypeList(unrequested, pass.Pkg)),
+				SuggestedFixes: fixes,
+			})
 		case len(unused) > 0:
-			// TODO(benkraft): If the identifier's type is an inline interface
-			// it would be nice to report on the line where each embedded
-			// interface is included in it.  This is surprisingly tricky to
-			// implement, so we just report at the identifier itself.
-			pass.Reportf(obj.Pos(),
-				"%s requests but does not use interface(s) %s; "+
-					"remove to use the smallest possible interface",
-				obj.Name(), _formatTypeList(unused, pass.Pkg))
+			// If the identifier's type is an inline interface, we report on
+			// the line where each unused interface is embedded in it.  (The
+			// fix fixes them all, so we attach it to the first.)
+			reported := false
+			if iface != nil {
+				for _, embed := range _embeds(iface) {
+					var embedUnused []types.Type
+					for _, leaf := range _leafInterfaces(pass.TypesInfo.TypeOf(embed.Type)) {
+						if _containsType(unused, leaf) {
+							embedUnused = append(embedUnused, leaf)
+						}
+					}
+					if len(embedUnused) == 0 {
+						continue
+					}
+					pass.Report(analysis.Diagnostic{
+						Pos: embed.Pos(),
+						Message: fmt.Sprintf(
+							"%s requests but does not use interface(s) %s; "+
+								"remove to use the smallest possible interface",
+							obj.Name(), _formatTypeList(embedUnused, pass.Pkg)),
+						SuggestedFixes: fixes,
+					})
+					fixes = nil
+					reported = true
+				}
+			}
+			// Otherwise (or if somehow no embed matched), we report them all
+			// on the identifier.
+			if !reported {
+				pass.Report(analysis.Diagnostic{
+					Pos: obj.Pos(),
+					Message: fmt.Sprintf(
+						"%s requests but does not use interface(s) %s; "+
+							"remove to use the smallest possible interface",
+						obj.Name(), _formatTypeList(unused, pass.Pkg)),
+					SuggestedFixes: fixes,
+				})
+			}
 		}
 	}
 
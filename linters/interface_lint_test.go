@@ -0,0 +1,27 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestKAContextInterfaceFix(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextInterfaceAnalyzer, "kactxiface")
+}
+
+func TestKAContextInterfaceRoot(t *testing.T) {
+	setFlag(t, linters.KAContextInterfaceAnalyzer, "root", "capdeps.Provider")
+	setFlag(t, linters.KAContextInterfaceAnalyzer, "base", "capdeps.Base")
+	setFlag(t, linters.KAContextInterfaceAnalyzer, "exempt", "capdeps.Testing")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextInterfaceAnalyzer, "capdeps")
+}
+
+func TestKAContextInterfaceFacts(t *testing.T) {
+	analysistest.Run(
+		t, analysistest.TestData(), linters.KAContextInterfaceAnalyzer, "kactxdep", "kactxuse")
+}
@@ -0,0 +1,159 @@
+package linters
+
+// This file defines which interfaces KAContextInterfaceAnalyzer checks.
+//
+// Nothing about the analysis is specific to kacontext: it works for any
+// codebase that passes dependencies (or "capabilities") around as an interface
+// made up of smaller interfaces, and wants each function to request only what
+// it uses.  By default we check kacontext-style contexts, but the root
+// interface (and a few related types) may be configured with flags, e.g.
+//	-kacontextinterface.root=example.com/pkg/deps.Provider
+// to check variables whose types embed deps.Provider instead.
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// _typeNameList is a list of named types, written "pkg/path.Name".  It
+// implements flag.Value, as a comma-separated list.
+type _typeNameList []string
+
+func (l *_typeNameList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *_typeNameList) Set(value string) error {
+	*l = nil
+	for _, name := range strings.Split(value, ",") {
+		if name == "" {
+			continue
+		}
+		if !strings.Contains(name, ".") {
+			return fmt.Errorf("invalid type %q: must be of the form pkg/path.Name", name)
+		}
+		*l = append(*l, name)
+	}
+
+	return nil
+}
+
+// _splitTypeName splits "pkg/path.Name" into "pkg/path" and "Name".
+func _splitTypeName(name string) (pkgPath, typeName string) {
+	i := strings.LastIndex(name, ".")
+
+	return name[:i], name[i+1:]
+}
+
+// matches returns true if typ is one of the types in the list.
+func (l _typeNameList) matches(typ types.Type) bool {
+	for _, name := range l {
+		pkgPath, typeName := _splitTypeName(name)
+		if lintutil.TypeIs(typ, pkgPath, typeName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _capabilityPattern describes the interfaces KAContextInterfaceAnalyzer
+// checks, and some special cases of how they're used.
+type _capabilityPattern struct {
+	// roots are the root interfaces: we check variables whose types are
+	// interfaces which (recursively) embed one of them.  If a variable
+	// requests only a root, we don't check it (that's a job for an
+	// unused-argument linter).
+	roots _typeNameList
+	// bases are interfaces which embed a root and little else, such that
+	// it's fine to request one if you only use the root.
+	bases _typeNameList
+	// exempt are types whose variables we never check.
+	exempt _typeNameList
+
+	// cacheFunc and keyParamsFunc are the functions of our caching library,
+	// if any, which wrap a function and may need any of cacheInterfaces from
+	// its context; see _markCachedFunctionUsed.
+	cacheFunc       string
+	keyParamsFunc   string
+	cacheInterfaces _typeNameList
+	// groupFunc is the function, if any, which wraps a context for use by
+	// several goroutines; see markTracegroupUses.
+	groupFunc string
+}
+
+// _capabilities is the pattern KAContextInterfaceAnalyzer checks; its roots,
+// bases, and exempt types may be set with flags.
+var _capabilities = _capabilityPattern{
+	roots: _typeNameList{"context.Context"},
+	bases: _typeNameList{kacontextPath + ".Base"},
+	// Tests can ask for TestContext, which is useful if you want to Clone(),
+	// or just if you want to make it obvious that you are a test util and
+	// tests should pass in their `suite.KAContext()`.  In any case, we'll
+	// allow it.
+	exempt: _typeNameList{kacontextPath + ".TestContext"},
+
+	cacheFunc:     "github.com/Khan/webapp/pkg/lib/cache.Cache",
+	keyParamsFunc: "github.com/Khan/webapp/pkg/lib/cache.KeyParamsFxn",
+	cacheInterfaces: _typeNameList{
+		// used by settingscache
+		kacontextPath + ".Base",
+		// used by datastore, settingscache, and Expiration
+		"github.com/Khan/webapp/pkg/lib/timectx.KAContext",
+		// used by memorystore and settingscache
+		"github.com/Khan/webapp/pkg/lib/log.KAContext",
+		// used by memorystore and settingscache
+		"github.com/Khan/webapp/pkg/gcloud/memorystore.KAContext",
+		// used by datastore and settingscache
+		"github.com/Khan/webapp/pkg/gcloud/datastore.KAContext",
+		// used by PersistAcrossPublish
+		"github.com/Khan/webapp/pkg/content.KAContext",
+		// common in key-params-fxns (or perhaps a future cache-option!)
+		//
+		// TODO(benkraft): Having a key-params-fxn ask for a context that
+		// the cached function doesn't use suggests that we are
+		// over-caching.  The problem is that the GraphQL client will
+		// forward these headers even if they aren't in your context.
+		// That's a recipe for caching bugs!
+		//
+		// Instead, we should do something like: have the GraphQL client
+		// ask for ka-locale-context if and only if we are forwarding the
+		// ka-locale header (and so on for other contexts).  It's unclear
+		// if there's a reasonable way to do that without generics, though.
+		"github.com/Khan/webapp/pkg/web.CountryContext",
+		"github.com/Khan/webapp/pkg/web.CurriculumContext",
+		"github.com/Khan/webapp/pkg/web.KALocaleContext",
+	},
+	groupFunc: "github.com/Khan/webapp/pkg/external/opentelemetry/tracegroup.WithContext",
+}
+
+func init() {
+	KAContextInterfaceAnalyzer.Flags.Var(&_capabilities.roots, "root",
+		"comma-separated root interfaces (pkg/path.Name) to check variables of")
+	KAContextInterfaceAnalyzer.Flags.Var(&_capabilities.bases, "base",
+		"comma-separated interfaces which may be requested if only a root is used")
+	KAContextInterfaceAnalyzer.Flags.Var(&_capabilities.exempt, "exempt",
+		"comma-separated interfaces whose variables are never checked")
+}
+
+// isCapabilityType returns true if the input is an interface we should check:
+// one of the roots, or an interface (recursively) embedding one.
+func (p *_capabilityPattern) isCapabilityType(typ types.Type) bool {
+	return p.embeddedRoot(typ) != nil
+}
+
+// embeddedRoot returns the root interface recursively embedded in the given
+// type (or the type itself, if it's a root), or nil if there is none.
+func (p *_capabilityPattern) embeddedRoot(typ types.Type) types.Type {
+	for _, root := range p.roots {
+		pkgPath, typeName := _splitTypeName(root)
+		if embed := _embedNamed(typ, pkgPath, typeName); embed != nil {
+			return embed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package linters
+
+// This file contains the facts JSONTagAnalyzer exports about the named types
+// it checked, so that when we analyze a package which JSONifies a type from
+// another package, we needn't traverse that type again, or report problems in
+// it which we already reported at its declaration.
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Fact exported for a *types.TypeName which was JSONified (or encoded with
+// another encoding) in its own package, and so which we checked, and whose
+// problems we reported, at its declaration.  We only export facts for the
+// encodings and directions with which the type was actually used; a package
+// which encodes the type in some other way checks it itself.
+//
+// See the docs for more about Facts:
+// https://pkg.go.dev/golang.org/x/tools/go/analysis?tab=doc#hdr-Modular_analysis_with_Facts
+type _missingTags struct {
+	// Marshal and Unmarshal map the tag of each encoding with which the type
+	// was encoded or decoded, respectively, to the paths to the fields which
+	// lack tags, relative to the type, like "Outer.Inner" (except those in
+	// other types which were reported at their own declarations).
+	Marshal   map[string][]string
+	Unmarshal map[string][]string
+}
+
+// AFact tells go/analysis that this is a valid fact type.
+func (*_missingTags) AFact() {}
+
+// String makes test-assertions work: we can say
+//
+//	type T struct { // want T:"_missingTags\(json: A\)"
+//
+// to assert that we checked the given type, and found the given problems.
+func (f *_missingTags) String() string {
+	var parts []string
+	for suffix, byEncoding := range map[string]map[string][]string{
+		"":          f.Marshal,
+		" (decode)": f.Unmarshal,
+	} {
+		for tag, paths := range byEncoding {
+			parts = append(parts, tag+suffix+": "+strings.Join(paths, ", "))
+		}
+	}
+	sort.Strings(parts)
+
+	return "_missingTags(" + strings.Join(parts, "; ") + ")"
+}
+
+// checked returns true if the type was checked in its own package for the
+// given encoding and direction.
+func (f *_missingTags) checked(encoding *_encoding, decodes bool) bool {
+	byEncoding := f.Marshal
+	if decodes {
+		byEncoding = f.Unmarshal
+	}
+	_, ok := byEncoding[encoding.tag]
+
+	return ok
+}
+
+// _exportMissingTags exports a _missingTags fact for each named type in this
+// package which we checked at its declaration, given the problems we found
+// for each.
+func _exportMissingTags(pass *analysis.Pass, checked map[_jsonTypeKey][]_missingTag) {
+	facts := map[*types.TypeName]*_missingTags{}
+	for key, problems := range checked {
+		fact := facts[key.obj]
+		if fact == nil {
+			fact = &_missingTags{}
+			facts[key.obj] = fact
+		}
+
+		paths := make([]string, len(problems))
+		for i, problem := range problems {
+			paths[i] = problem.path
+		}
+		sort.Strings(paths)
+
+		byEncoding := &fact.Marshal
+		if key.decodes {
+			byEncoding = &fact.Unmarshal
+		}
+		if *byEncoding == nil {
+			*byEncoding = map[string][]string{}
+		}
+		(*byEncoding)[key.encoding.tag] = paths
+	}
+
+	for obj, fact := range facts {
+		pass.ExportObjectFact(obj, fact)
+	}
+}
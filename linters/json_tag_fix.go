@@ -0,0 +1,267 @@
+package linters
+
+// This file contains the suggested fixes for JSONTagAnalyzer, which add the
+// missing struct tags to the fields that need them.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _jsonTagNaming is the naming strategy for the tags we add, set via the
+// -json_tag.naming flag: "go" (UserID, i.e. the same key the encoding would
+// use without a tag, so that adding the tag doesn't change what's encoded),
+// "camel" (userID), or "snake" (user_id).
+var _jsonTagNaming string
+
+func init() {
+	JSONTagAnalyzer.Flags.StringVar(&_jsonTagNaming, "naming", "go",
+		`naming strategy for tags added by suggested fixes: "go", "camel", or "snake"`)
+}
+
+// _tagName returns the name we give a field in the tags we add, according to
+// the configured naming strategy.
+func _tagName(fieldName string) (string, error) {
+	switch _jsonTagNaming {
+	case "go":
+		return fieldName, nil
+	case "camel":
+		words := _parseMixedCaps(fieldName)
+		words[0] = strings.ToLower(words[0])
+
+		return strings.Join(words, ""), nil
+	case "snake":
+		words := _parseMixedCaps(fieldName)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+
+		return strings.Join(words, "_"), nil
+	default:
+		return "", fmt.Errorf(
+			`invalid -json_tag.naming %q: must be "go", "camel", or "snake"`,
+			_jsonTagNaming)
+	}
+}
+
+// _structTagRegexp matches a single key:"value" pair in a struct tag.
+var _structTagRegexp = regexp.MustCompile(`([^\s:"]+):"((?:[^"\\]|\\.)*)"`)
+
+// _hasOmitEmpty returns true if any key in the given struct tag has the
+// omitempty option, e.g. `yaml:"name,omitempty"`.
+func _hasOmitEmpty(tag string) bool {
+	for _, match := range _structTagRegexp.FindAllStringSubmatch(tag, -1) {
+		options := strings.Split(match[2], ",")[1:]
+		if _contains(options, "omitempty") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _fieldsByPos returns the struct fields declared in this package, by the
+// position of their name (or, for embedded fields, their type), which is the
+// position go/types uses for them.
+func _fieldsByPos(pass *analysis.Pass) map[token.Pos]*ast.Field {
+	retval := map[token.Pos]*ast.Field{}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			structType, ok := node.(*ast.StructType)
+			if !ok {
+				return true // recurse
+			}
+			for _, field := range structType.Fields.List {
+				if len(field.Names) == 0 {
+					retval[_embeddedFieldPos(field.Type)] = field
+				}
+				for _, name := range field.Names {
+					retval[name.Pos()] = field
+				}
+			}
+
+			return true
+		})
+	}
+
+	return retval
+}
+
+// _isStructOrStructPointer returns true if typ is a struct or a pointer to
+// one.
+func _isStructOrStructPointer(typ types.Type) bool {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	_, ok := typ.Underlying().(*types.Struct)
+
+	return ok
+}
+
+// _embeddedFieldPos returns the position go/types uses for an embedded field
+// of the given type: that of the type-name, without any `*` or package.
+func _embeddedFieldPos(typ ast.Expr) token.Pos {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if sel, ok := typ.(*ast.SelectorExpr); ok {
+		return sel.Sel.Pos()
+	}
+
+	return typ.Pos()
+}
+
+// _newTag returns the tag to add for the given encoding to a field lacking
+// one, e.g. `json:"UserID"`, or ok=false if we don't know what it should be.
+func _newTag(encoding *_encoding, problem _missingTag) (tag string, ok bool) {
+	name, err := _tagName(problem.field.Name())
+	if err != nil {
+		// We report this as an error from the pass: see
+		// _validateJSONTagNaming.
+		return "", false
+	}
+	switch {
+	case !_isStructOrStructPointer(problem.field.Type()) || !problem.field.Embedded():
+		if _hasOmitEmpty(problem.tag) {
+			name += ",omitempty"
+		}
+	case !encoding.inlinesEmbedded:
+		// Encodings like yaml and bson encode an embedded struct as a
+		// nested object unless it's explicitly inlined, which is
+		// presumably what was intended.
+		name = ",inline"
+	default:
+		// Other encodings already inline embedded structs (and don't
+		// support `,inline`); we don't know what's wanted here.
+		return "", false
+	}
+
+	return fmt.Sprintf(`%v:"%v"`, encoding.tag, name), true
+}
+
+// _fixableField returns the declaration of the field with the given problem,
+// if it's declared in this package and we can safely fix it (that is, it
+// doesn't share a declaration with other fields, as in `A, B string`).
+func _fixableField(
+	pkg *types.Package,
+	fields map[token.Pos]*ast.Field,
+	problem _missingTag,
+) *ast.Field {
+	if problem.field.Pkg() != pkg {
+		return nil // in another package
+	}
+	field, ok := fields[problem.field.Pos()]
+	if !ok || len(field.Names) > 1 {
+		return nil
+	}
+
+	return field
+}
+
+// _newTagsByField returns the tags we'll add to each field, for all the
+// encodings for which it has problems, in a consistent order.
+//
+// A field may lack tags for several encodings, each reported separately.  We
+// add all of them in each fix, so that when several fixes are applied at once
+// (as with -fix) their edits are identical, and get merged, rather than
+// inserting separate struct tags for each encoding side by side.
+func _newTagsByField(
+	pkg *types.Package,
+	fields map[token.Pos]*ast.Field,
+	problemsByEncoding map[*_encoding][][]_missingTag,
+) map[*ast.Field][]string {
+	retval := map[*ast.Field][]string{}
+	for encoding, problemLists := range problemsByEncoding {
+		for _, problems := range problemLists {
+			for _, problem := range problems {
+				field := _fixableField(pkg, fields, problem)
+				if field == nil {
+					continue
+				}
+				tag, ok := _newTag(encoding, problem)
+				if ok && !_contains(retval[field], tag) {
+					retval[field] = append(retval[field], tag)
+				}
+			}
+		}
+	}
+	for _, tags := range retval {
+		sort.Strings(tags)
+	}
+
+	return retval
+}
+
+// _addTagsFixes returns a suggested fix that adds the missing tags to each of
+// the fields with the given problems (with the given encoding), where they're
+// declared in this package.  newTags should be the return value of
+// _newTagsByField.
+//
+// Fields declared elsewhere, or that we can't safely fix (because they share
+// a declaration with other fields, as in `A, B string`, or have an unusual
+// tag), are skipped; if there are none left we return no fixes.
+func _addTagsFixes(
+	pkg *types.Package,
+	fields map[token.Pos]*ast.Field,
+	encoding *_encoding,
+	problems []_missingTag,
+	newTags map[*ast.Field][]string,
+) []analysis.SuggestedFix {
+	var edits []analysis.TextEdit
+	seen := map[*ast.Field]bool{}
+	for _, problem := range problems {
+		field := _fixableField(pkg, fields, problem)
+		if field == nil || seen[field] || len(newTags[field]) == 0 {
+			continue
+		}
+		seen[field] = true
+		newTag := strings.Join(newTags[field], " ")
+
+		switch {
+		case field.Tag == nil:
+			edits = append(edits, analysis.TextEdit{
+				Pos:     field.Type.End(),
+				End:     field.Type.End(),
+				NewText: []byte(" `" + newTag + "`"),
+			})
+		case strings.HasPrefix(field.Tag.Value, "`"):
+			// Add to the end of the existing tag, so as to preserve it.
+			closingQuote := field.Tag.End() - 1
+			if strings.TrimSpace(field.Tag.Value[1:len(field.Tag.Value)-1]) != "" {
+				newTag = " " + newTag
+			}
+			edits = append(edits, analysis.TextEdit{
+				Pos:     closingQuote,
+				End:     closingQuote,
+				NewText: []byte(newTag),
+			})
+		default:
+			// The tag is a double-quoted string; rather than deal with
+			// escaping, we leave it to the human.
+		}
+	}
+
+	if len(edits) == 0 {
+		return nil
+	}
+
+	return []analysis.SuggestedFix{{
+		Message:   fmt.Sprintf("Add %v tags", encoding.tag),
+		TextEdits: edits,
+	}}
+}
+
+// _validateJSONTagNaming returns an error if the -json_tag.naming flag is
+// invalid.
+func _validateJSONTagNaming() error {
+	_, err := _tagName("X")
+
+	return err
+}
@@ -6,9 +6,11 @@ package linters
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
@@ -33,74 +35,248 @@ import (
 // For further documentation on how to define json tags, see
 // `go doc encoding/json.Marshal`.
 //
-// TODO(benkraft): If other encodings like gob and xml become common, apply the
-// same rules to them as well.
-// TODO(benkraft): Search for types used in struct fields tagged with
-// `kadatastore_json`, and validate those as well.
-// TODO(benkraft): Search for structs which have a JSON tag, and assume that
-// means they are JSONified, perhaps via an empty interface, or other code that
-// this linter can't trace.
+// The same rules apply to the other tag-based encodings we know about (see
+// _encodings): structs encoded with encoding/xml must have xml tags, those
+// encoded with gopkg.in/yaml.v3 must have yaml tags, and so on.  (There's
+// nothing to check for encoding/gob, which has no struct tags: it always uses
+// the Go field names.)
+//
+// Problems with a named type are reported once, at the type's declaration,
+// rather than everywhere it's JSONified; at the call to json.Marshal (or
+// similar) we report only problems with anonymous types, and with types from
+// other packages that aren't JSONified in their own package.
+//
+// Each diagnostic comes with a suggested fix which adds the missing tags, at
+// least for fields declared in the package being linted.  The tag names are
+// chosen according to the -json_tag.naming flag: "go" (the default, e.g.
+// `json:"UserID"` for a field UserID, which preserves the current encoding),
+// "camel" (`json:"userID"`), or "snake" (`json:"user_id"`).  If any of the
+// field's existing tags has the omitempty option, we add it to the new tag
+// too.
+//
+// Besides calls to the encoding functions, we consider two other things to
+// mean a type is JSONified:
+//   - the type of a struct field tagged `kadatastore_json`, which kadatastore
+//     stores as JSON;
+//   - a struct type which already has a json tag on at least one field (or an
+//     xml tag, and so on), which we assume means it's encoded somewhere, even
+//     if we can't trace it (perhaps it's passed via an interface{}).  In this
+//     case we report at the type's declaration.
 var JSONTagAnalyzer = &analysis.Analyzer{
 	Name: "json_tag",
-	Doc:  "bans JSONifying structs without explicit tags",
+	Doc:  "bans JSONifying (or XML/YAML/BSON/msgpack-encoding) structs without explicit tags",
 	Run:  _runJSON,
+	// We export the problems with each named type, so that we don't have to
+	// traverse (or report) them again in each package that JSONifies them;
+	// see json_tag_facts.go.
+	FactTypes: []analysis.Fact{new(_missingTags)},
 }
 
-// A function we want to look for which does JSON marshaling or unmarshaling,
-// like json.Marshal.  These are typically in the encoding/json package, but in
-// theory could be elsewhere, say if we start using a third-party JSON library.
-type _jsonifyingFunction struct {
-	name      string
-	marshaler string
-	argIndex  int
+// An encoding, like JSON, which encodes structs according to their struct
+// tags.
+type _encoding struct {
+	// tag is the struct tag key the encoding uses, e.g. "json".
+	tag string
+	// description describes a value encoded with this encoding, for use in
+	// error messages, e.g. "JSONified".
+	description string
+	// inlinesEmbedded is true if embedded structs without a tag are encoded
+	// as if their fields were fields of the outer struct (as in
+	// encoding/json), rather than as a nested object (as in yaml, which
+	// requires an explicit `yaml:",inline"`).
+	inlinesEmbedded bool
+	// ignoredFields are names of fields with special meaning to the
+	// encoding, which don't need a tag (e.g. XMLName for encoding/xml).
+	ignoredFields []string
+	// marshalers and unmarshalers are the interfaces which mean a type fully
+	// defines how it is encoded or decoded (e.g. json.Marshaler and
+	// json.Unmarshaler, as well as encoding.TextMarshaler and
+	// encoding.TextUnmarshaler, which encoding/json and encoding/xml use to
+	// encode the type as a string).
+	marshalers   []_marshalerInterface
+	unmarshalers []_marshalerInterface
+	// functions are the functions which encode or decode values with this
+	// encoding.
+	functions []_jsonifyingFunction
 }
 
-// Given the package in which this jsonifier is defined, return the actual
-// *types.Interface representing its marshaler.
-func (jsonifier _jsonifyingFunction) getMarshalInterface(pkg *types.Package) *types.Interface {
-	obj := pkg.Scope().Lookup(jsonifier.marshaler)
-	if obj == nil {
-		// likely means that the list of jsonifying functions itself is
-		// wrong, so just panic
-		panic(fmt.Sprintf("couldn't find marshaler interface %v in %v",
-			jsonifier.marshaler, pkg.Path()))
-	}
-	underlyingType := obj.Type().Underlying()
-	underlyingInterface, ok := underlyingType.(*types.Interface)
-	if !ok {
-		// again, likely a problem in the list of jsonifying functions
-		panic(fmt.Sprintf("marshaler %v.%v is %T, not interface",
-			pkg.Path(), jsonifier.marshaler, underlyingType))
-	}
+// A single-method interface, like json.Marshaler, which a type implements to
+// define its own encoding (or decoding).  Rather than look up the interface
+// itself, which may be declared in a package we don't import, we describe
+// its method; the types of its parameters and results are written as for
+// _resolveTypeName.
+type _marshalerInterface struct {
+	method          string
+	params, results []string
+}
 
-	return underlyingInterface
+var (
+	_textMarshaler   = _marshalerInterface{"MarshalText", nil, []string{"[]byte", "error"}}
+	_textUnmarshaler = _marshalerInterface{"UnmarshalText", []string{"[]byte"}, []string{"error"}}
+)
+
+// A function we want to look for which does JSON (or other) marshaling or
+// unmarshaling, like json.Marshal.
+type _jsonifyingFunction struct {
+	name string
+	// decodes is true if this function unmarshals into its argument (rather
+	// than marshaling from it).
+	decodes  bool
+	argIndex int
+}
+
+// _encodings are the encodings we know about, by their struct tag.
+var _encodings = map[string]*_encoding{
+	"json": {
+		tag:             "json",
+		description:     "JSONified",
+		inlinesEmbedded: true,
+		marshalers: []_marshalerInterface{
+			{"MarshalJSON", nil, []string{"[]byte", "error"}},
+			_textMarshaler,
+		},
+		unmarshalers: []_marshalerInterface{
+			{"UnmarshalJSON", []string{"[]byte"}, []string{"error"}},
+			_textUnmarshaler,
+		},
+		functions: []_jsonifyingFunction{
+			{"encoding/json.Marshal", false, 0},
+			{"encoding/json.MarshalIndent", false, 0},
+			{"encoding/json.Unmarshal", true, 1},
+			{"(*encoding/json.Decoder).Decode", true, 0},
+			{"(*encoding/json.Encoder).Encode", false, 0},
+		},
+	},
+	"xml": {
+		tag:             "xml",
+		description:     "XML-encoded",
+		inlinesEmbedded: true,
+		ignoredFields:   []string{"XMLName"},
+		marshalers: []_marshalerInterface{
+			{
+				"MarshalXML",
+				[]string{"*encoding/xml.Encoder", "encoding/xml.StartElement"},
+				[]string{"error"},
+			},
+			_textMarshaler,
+		},
+		unmarshalers: []_marshalerInterface{
+			{
+				"UnmarshalXML",
+				[]string{"*encoding/xml.Decoder", "encoding/xml.StartElement"},
+				[]string{"error"},
+			},
+			_textUnmarshaler,
+		},
+		functions: []_jsonifyingFunction{
+			{"encoding/xml.Marshal", false, 0},
+			{"encoding/xml.MarshalIndent", false, 0},
+			{"encoding/xml.Unmarshal", true, 1},
+			{"(*encoding/xml.Decoder).Decode", true, 0},
+			{"(*encoding/xml.Decoder).DecodeElement", true, 0},
+			{"(*encoding/xml.Encoder).Encode", false, 0},
+			{"(*encoding/xml.Encoder).EncodeElement", false, 0},
+		},
+	},
+	"yaml": {
+		tag:             "yaml",
+		description:     "YAML-encoded",
+		inlinesEmbedded: false,
+		marshalers: []_marshalerInterface{
+			{"MarshalYAML", nil, []string{"interface{}", "error"}},
+		},
+		unmarshalers: []_marshalerInterface{
+			{"UnmarshalYAML", []string{"*gopkg.in/yaml.v3.Node"}, []string{"error"}},
+			// yaml.v3 still supports yaml.v2's Unmarshaler.
+			{"UnmarshalYAML", []string{"func(interface{}) error"}, []string{"error"}},
+		},
+		functions: []_jsonifyingFunction{
+			{"gopkg.in/yaml.v3.Marshal", false, 0},
+			{"gopkg.in/yaml.v3.Unmarshal", true, 1},
+			{"(*gopkg.in/yaml.v3.Decoder).Decode", true, 0},
+			{"(*gopkg.in/yaml.v3.Encoder).Encode", false, 0},
+		},
+	},
+	"bson": {
+		tag:             "bson",
+		description:     "BSON-encoded",
+		inlinesEmbedded: false,
+		marshalers: []_marshalerInterface{
+			{"MarshalBSON", nil, []string{"[]byte", "error"}},
+			{
+				"MarshalBSONValue",
+				nil,
+				[]string{"go.mongodb.org/mongo-driver/bson/bsontype.Type", "[]byte", "error"},
+			},
+		},
+		unmarshalers: []_marshalerInterface{
+			{"UnmarshalBSON", []string{"[]byte"}, []string{"error"}},
+			{
+				"UnmarshalBSONValue",
+				[]string{"go.mongodb.org/mongo-driver/bson/bsontype.Type", "[]byte"},
+				[]string{"error"},
+			},
+		},
+		functions: []_jsonifyingFunction{
+			{"go.mongodb.org/mongo-driver/bson.Marshal", false, 0},
+			{"go.mongodb.org/mongo-driver/bson.MarshalExtJSON", false, 0},
+			{"go.mongodb.org/mongo-driver/bson.Unmarshal", true, 1},
+			{"go.mongodb.org/mongo-driver/bson.UnmarshalExtJSON", true, 2},
+			{"(*go.mongodb.org/mongo-driver/bson.Decoder).Decode", true, 0},
+		},
+	},
+	"msgpack": {
+		tag:             "msgpack",
+		description:     "msgpack-encoded",
+		inlinesEmbedded: true,
+		marshalers: []_marshalerInterface{
+			{"MarshalMsgpack", nil, []string{"[]byte", "error"}},
+			{
+				"EncodeMsgpack",
+				[]string{"*github.com/vmihailenco/msgpack/v5.Encoder"},
+				[]string{"error"},
+			},
+		},
+		unmarshalers: []_marshalerInterface{
+			{"UnmarshalMsgpack", []string{"[]byte"}, []string{"error"}},
+			{
+				"DecodeMsgpack",
+				[]string{"*github.com/vmihailenco/msgpack/v5.Decoder"},
+				[]string{"error"},
+			},
+		},
+		functions: []_jsonifyingFunction{
+			{"github.com/vmihailenco/msgpack/v5.Marshal", false, 0},
+			{"github.com/vmihailenco/msgpack/v5.Unmarshal", true, 1},
+			{"(*github.com/vmihailenco/msgpack/v5.Decoder).Decode", true, 0},
+			{"(*github.com/vmihailenco/msgpack/v5.Encoder).Encode", false, 0},
+		},
+	},
 }
 
-var _jsonifyingFunctions = []_jsonifyingFunction{
-	{"encoding/json.Marshal", "Marshaler", 0},
-	{"encoding/json.MarshalIndent", "Marshaler", 0},
-	{"encoding/json.Unmarshal", "Unmarshaler", 1},
-	{"(*encoding/json.Decoder).Decode", "Unmarshaler", 0},
-	{"(*encoding/json.Encoder).Encode", "Marshaler", 0},
+// A jsonifying function along with its encoding.
+type _encodingFunction struct {
+	encoding *_encoding
+	function _jsonifyingFunction
 }
 
-var _jsonifyingFunctionsByName = map[string]_jsonifyingFunction{}
+var _jsonifyingFunctionsByName = map[string]_encodingFunction{}
 
 func init() {
-	for _, f := range _jsonifyingFunctions {
-		_jsonifyingFunctionsByName[f.name] = f
+	for _, encoding := range _encodings {
+		for _, f := range encoding.functions {
+			_jsonifyingFunctionsByName[f.name] = _encodingFunction{encoding, f}
+		}
 	}
 }
 
 type _jsonifiedValue struct {
 	node ast.Node   // node where this value is jsonified
 	typ  types.Type // type into/out of which we jsonify
-	// marshalInterface stores a reference to the relevant
-	// marshaler/unmarshaler interface, depending on whether this function is
-	// an encoder or a decoder.  We store it here because it's surprisingly
-	// hard to look up a function by name with go/types, but it's very easy if
-	// you have a reference to the function in the same package.
-	marshalInterface *types.Interface
+	// encoding is the encoding with which we encode/decode the value.
+	encoding *_encoding
+	// decodes is true if we decode into the value, rather than encoding it.
+	decodes bool
 }
 
 func _getJSONifiedValues(pass *analysis.Pass) []_jsonifiedValue {
@@ -116,24 +292,213 @@ func _getJSONifiedValues(pass *analysis.Pass) []_jsonifiedValue {
 			funcName := lintutil.NameOf(funcObj)
 			jsonifier, ok := _jsonifyingFunctionsByName[funcName]
 			// len check is just to be safe, it should never happen
-			if !ok || jsonifier.argIndex >= len(callExpr.Args) {
+			if !ok || jsonifier.function.argIndex >= len(callExpr.Args) {
 				return true
 			}
 
-			arg := callExpr.Args[jsonifier.argIndex]
+			arg := callExpr.Args[jsonifier.function.argIndex]
 			retval = append(retval, _jsonifiedValue{
-				node:             arg,
-				typ:              pass.TypesInfo.TypeOf(arg),
-				marshalInterface: jsonifier.getMarshalInterface(funcObj.Pkg()),
+				node:     arg,
+				typ:      pass.TypesInfo.TypeOf(arg),
+				encoding: jsonifier.encoding,
+				decodes:  jsonifier.function.decodes,
 			})
 
 			return true
 		})
 	}
 
+	return append(retval, _getTaggedValues(pass)...)
+}
+
+// _kadatastoreJSONTag is the struct tag kadatastore uses to mark a field which
+// it stores as JSON.
+const _kadatastoreJSONTag = "kadatastore_json"
+
+// _getTaggedValues returns the values we know to be JSONified from the struct
+// tags in this package (see JSONTagAnalyzer for details).
+func _getTaggedValues(pass *analysis.Pass) []_jsonifiedValue {
+	var retval []_jsonifiedValue
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.TypeSpec:
+				structType, ok := node.Type.(*ast.StructType)
+				if !ok {
+					return true // recurse
+				}
+				for _, encoding := range _encodings {
+					if !_hasTag(structType, encoding.tag) {
+						continue
+					}
+					retval = append(retval, _jsonifiedValue{
+						node:     node.Name,
+						typ:      pass.TypesInfo.TypeOf(node.Name),
+						encoding: encoding,
+					})
+				}
+			case *ast.Field:
+				if node.Tag == nil {
+					return true
+				}
+				tag, err := strconv.Unquote(node.Tag.Value)
+				if err != nil {
+					return true
+				}
+				if _, ok := reflect.StructTag(tag).Lookup(_kadatastoreJSONTag); !ok {
+					return true
+				}
+				// kadatastore both stores and loads the value.
+				for _, decodes := range []bool{false, true} {
+					retval = append(retval, _jsonifiedValue{
+						node:     node,
+						typ:      pass.TypesInfo.TypeOf(node.Type),
+						encoding: _encodings["json"],
+						decodes:  decodes,
+					})
+				}
+			}
+
+			return true
+		})
+	}
+
 	return retval
 }
 
+// _hasTag returns true if any field of the given struct has a struct tag
+// with the given key.
+func _hasTag(structType *ast.StructType, key string) bool {
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		if _, ok := reflect.StructTag(tag).Lookup(key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _packagesByPath returns the given package and all the packages it
+// (transitively) imports, by import path.  Any type we might analyze, and
+// any type its methods refer to, is declared in one of them.
+func _packagesByPath(pkg *types.Package) map[string]*types.Package {
+	retval := map[string]*types.Package{}
+	var visit func(pkg *types.Package)
+	visit = func(pkg *types.Package) {
+		if retval[pkg.Path()] != nil {
+			return
+		}
+		retval[pkg.Path()] = pkg
+		for _, imp := range pkg.Imports() {
+			visit(imp)
+		}
+	}
+	visit(pkg)
+
+	return retval
+}
+
+// _resolveTypeName returns the type with the given name, which is one of
+// "[]byte", "error", "interface{}", "func(interface{}) error", or a
+// (pointer to a) named type written like "*encoding/xml.Encoder".  It returns
+// nil if the type is declared in a package we don't know about.
+func _resolveTypeName(name string, packages map[string]*types.Package) types.Type {
+	emptyInterface := types.NewInterfaceType(nil, nil).Complete()
+	errorType := types.Universe.Lookup("error").Type()
+	switch name {
+	case "[]byte":
+		return types.NewSlice(types.Typ[types.Byte])
+	case "error":
+		return errorType
+	case "interface{}":
+		return emptyInterface
+	case "func(interface{}) error":
+		return types.NewSignature(nil,
+			types.NewTuple(types.NewVar(token.NoPos, nil, "", emptyInterface)),
+			types.NewTuple(types.NewVar(token.NoPos, nil, "", errorType)),
+			false)
+	}
+
+	if strings.HasPrefix(name, "*") {
+		elem := _resolveTypeName(name[1:], packages)
+		if elem == nil {
+			return nil
+		}
+
+		return types.NewPointer(elem)
+	}
+
+	sep := strings.LastIndex(name, ".")
+	pkg := packages[name[:sep]]
+	if pkg == nil {
+		return nil
+	}
+	typeName, ok := pkg.Scope().Lookup(name[sep+1:]).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+
+	return typeName.Type()
+}
+
+// _marshalerInterfaces returns the given interfaces as go/types interfaces.
+// It omits those which refer to types declared in packages we don't know
+// about, since no type we analyze can implement them.
+func _marshalerInterfaces(
+	marshalers []_marshalerInterface,
+	packages map[string]*types.Package,
+) []*types.Interface {
+	tuple := func(names []string) *types.Tuple {
+		vars := make([]*types.Var, len(names))
+		for i, name := range names {
+			typ := _resolveTypeName(name, packages)
+			if typ == nil {
+				return nil
+			}
+			vars[i] = types.NewVar(token.NoPos, nil, "", typ)
+		}
+
+		return types.NewTuple(vars...)
+	}
+
+	var retval []*types.Interface
+	for _, marshaler := range marshalers {
+		params, results := tuple(marshaler.params), tuple(marshaler.results)
+		if (params == nil && len(marshaler.params) > 0) || results == nil {
+			continue
+		}
+		method := types.NewFunc(token.NoPos, nil, marshaler.method,
+			types.NewSignature(nil, params, results, false))
+		retval = append(retval, types.NewInterfaceType([]*types.Func{method}, nil).Complete())
+	}
+
+	return retval
+}
+
+// _implementsAny returns true if the given type, or a pointer to it,
+// implements any of the given interfaces.
+func _implementsAny(typ types.Type, interfaces []*types.Interface) bool {
+	for _, iface := range interfaces {
+		// We also allow if the pointer to the type implements the interface,
+		// because the encoders do (e.g. if you have a struct field, and a
+		// pointer to that type implements Marshaler.)
+		// TODO(benkraft): I'm not totally sure if there are edge cases where
+		// this isn't allowed; if we find any, add them.
+		if types.Implements(typ, iface) || types.Implements(types.NewPointer(typ), iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type stringStack struct {
 	// the stack is buf[:length]; the rest of buf is buffer to reuse
 	buf    []string
@@ -165,17 +530,35 @@ func (s *stringStack) read() []string {
 }
 
 type _typeAnalyzer struct {
-	marshalInterface *types.Interface
-	seen             map[types.Type]bool
-	path             stringStack
+	pass     *analysis.Pass
+	encoding *_encoding
+	decodes  bool
+	// marshalers are the interfaces which mean a type fully defines its
+	// encoding (the encoding's marshalers or unmarshalers, as appropriate).
+	marshalers []*types.Interface
+	seen       map[types.Type]bool
+	path       stringStack
+
+	// If local is non-nil, we don't traverse the named types declared in
+	// this package (other than root), but instead add them to local, so
+	// that they can be checked (and reported) at their declarations.
+	local *[]*types.TypeName
+	root  types.Type
 }
 
-// Analyze the given type, and return paths to any fields that need JSON tags.
-//
-// The argument marshalInterface is documented at
-// _jsonifiedValue.marshalInterface; path should be "" on the first call and is
-// used for recursion.
-func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
+// A field that needs a tag.
+type _missingTag struct {
+	// path is the path to the field from the type we are analyzing, like
+	// "Outer.Inner".
+	path string
+	// field is the field itself, and tag its existing struct tag (for
+	// other keys), if any.
+	field *types.Var
+	tag   string
+}
+
+// Analyze the given type, and return any fields that need tags.
+func (analyzer *_typeAnalyzer) run(typ types.Type) []_missingTag {
 	// avoid infinite recursion
 	if analyzer.seen[typ] {
 		return nil
@@ -184,21 +567,12 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 
 	// If this type implements Marshaler/Unmarshaler (as appropriate),
 	// it presumptively knows what it's doing.
-	// TODO(benkraft): Also allow TextMarshaler/TextUnmarshaler, which
-	// encoding/json knows how to use (and are more convenient when the type
-	// will be marshaled to a string).
-	if types.Implements(typ, analyzer.marshalInterface) ||
-		// We also allow if the pointer to the type implements the interface,
-		// because JSON does (e.g. if you have a struct field, and a pointer to
-		// that type implements Marshaler.)
-		// TODO(benkraft): I'm not totally sure if there are edge cases where
-		// this isn't allowed; if we find any, add them.
-		types.Implements(types.NewPointer(typ), analyzer.marshalInterface) {
+	if _implementsAny(typ, analyzer.marshalers) {
 		return nil
 	}
 
 	// otherwise, traverse the type, and see what we find.
-	var badPaths []string
+	var badPaths []_missingTag
 	recurse := func(typ types.Type, pathSuffix string) {
 		if pathSuffix != "" {
 			analyzer.path.push(pathSuffix)
@@ -207,13 +581,15 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 
 		badPaths = append(badPaths, analyzer.run(typ)...)
 	}
-	complain := func(pathSuffix string) {
-		if pathSuffix != "" {
-			analyzer.path.push(pathSuffix)
-			defer analyzer.path.pop()
-		}
-
-		badPaths = append(badPaths, strings.Join(analyzer.path.read(), "."))
+	complain := func(field *types.Var, tag string) {
+		analyzer.path.push(field.Name())
+		defer analyzer.path.pop()
+
+		badPaths = append(badPaths, _missingTag{
+			path:  strings.Join(analyzer.path.read(), "."),
+			field: field,
+			tag:   tag,
+		})
 	}
 
 	// cases from https://github.com/golang/example/tree/master/gotypes#types
@@ -227,6 +603,27 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 		// recurse on value only; key must be string-ish
 		recurse(typ.Elem(), "[value]")
 	case *types.Named:
+		obj := typ.Obj()
+		switch {
+		case !_isPackageLevel(obj):
+			// local to a function; we just treat it like an anonymous type.
+		case obj.Pkg() != analyzer.pass.Pkg:
+			// If this type was encoded this way in its own package, we
+			// checked it, and reported any problems, there.  Otherwise, we
+			// check it here, just like a type in this package.
+			var fact _missingTags
+			if analyzer.pass.ImportObjectFact(obj, &fact) &&
+				fact.checked(analyzer.encoding, analyzer.decodes) {
+				return badPaths
+			}
+		case typ == analyzer.root:
+		case analyzer.local != nil:
+			// we'll check this type at its declaration.
+			*analyzer.local = append(*analyzer.local, obj)
+
+			return badPaths
+		}
+
 		// if this type had a marshaler/unmarshaler method, we handled that
 		// above, so just recurse on the underlying type (e.g. struct fields)
 		recurse(typ.Underlying(), "")
@@ -241,13 +638,16 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 			if !field.Exported() {
 				continue // json won't see this at all
 			}
+			if _contains(analyzer.encoding.ignoredFields, field.Name()) {
+				continue // has a special meaning to this encoding
+			}
 
-			_, isStruct := field.Type().Underlying().(*types.Struct)
-			tag, ok := reflect.StructTag(typ.Tag(i)).Lookup("json")
+			isStruct := _isStructOrStructPointer(field.Type())
+			tag, ok := reflect.StructTag(typ.Tag(i)).Lookup(analyzer.encoding.tag)
 			switch {
-			// Embedded structs are encoded as if their inner exported
-			// fields were fields of the outer struct, unless they have an
-			// explicit struct tag.  So we need to recurse on them, but we
+			// Embedded structs (or pointers to structs) are encoded as if
+			// their inner exported fields were fields of the outer struct,
+			// unless they have an explicit struct tag.  So we need to recurse on them, but we
 			// *don't* need to complain if they lack a tag.
 			//
 			// For example, if we have
@@ -261,8 +661,11 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 			// then when we JSONify we'll get a single object with keys "a" and
 			// "b".  We need to recurse through U, to check that B has a struct
 			// tag.  But we don't need U itself to have a struct tag.
-			case !ok && (!isStruct || !field.Embedded()):
-				complain(field.Name())
+			//
+			// (Some encodings, like yaml, don't do this: they need an
+			// explicit tag to inline the struct.)
+			case !ok && (!isStruct || !field.Embedded() || !analyzer.encoding.inlinesEmbedded):
+				complain(field, typ.Tag(i))
 			case tag == "-":
 				continue // ignored by json, no need to recurse
 			}
@@ -271,43 +674,192 @@ func (analyzer *_typeAnalyzer) run(typ types.Type) []string {
 		}
 	case *types.Tuple: // not a value that can be passed
 	default:
+		// Newer versions of go/types have other types, like aliases, whose
+		// underlying type is one of the above.
+		if underlying := typ.Underlying(); underlying != typ {
+			recurse(underlying, "")
+
+			break
+		}
+
 		panic(fmt.Sprintf("unexpected type %v (%T)", typ, typ))
 	}
 
 	return badPaths
 }
 
+// _contains returns true if the given slice contains the given string.
+func _contains(slice []string, s string) bool {
+	for _, elem := range slice {
+		if elem == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _isPackageLevel returns true if the given object is declared at package
+// level (rather than within a function, or in the universe scope).
+func _isPackageLevel(obj types.Object) bool {
+	return obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+}
+
+// _newTypeAnalyzer returns a _typeAnalyzer for values encoded (or decoded,
+// if decodes is set) with the given encoding.  packages are the packages
+// visible to pass, as returned by _packagesByPath.
+func _newTypeAnalyzer(
+	pass *analysis.Pass,
+	encoding *_encoding,
+	decodes bool,
+	packages map[string]*types.Package,
+) *_typeAnalyzer {
+	marshalers := encoding.marshalers
+	if decodes {
+		marshalers = encoding.unmarshalers
+	}
+
+	return &_typeAnalyzer{
+		pass:       pass,
+		encoding:   encoding,
+		decodes:    decodes,
+		marshalers: _marshalerInterfaces(marshalers, packages),
+		seen:       map[types.Type]bool{},
+	}
+}
+
+// A named type declared in this package, which we check at its declaration,
+// along with the encoding and direction with which we check it.
+type _jsonTypeKey struct {
+	obj      *types.TypeName
+	encoding *_encoding
+	decodes  bool
+}
+
 func _runJSON(pass *analysis.Pass) (interface{}, error) {
-	// map from [type, marshal-interface] to its problems.
-	problemsCache := map[[2]types.Type][]string{}
+	err := _validateJSONTagNaming()
+	if err != nil {
+		return nil, err
+	}
+
+	// The diagnostics to report, along with the problems for which we'll
+	// suggest fixes once we've found them all; see _newTagsByField.
+	type pendingReport struct {
+		diagnostic analysis.Diagnostic
+		encoding   *_encoding
+		problems   []_missingTag
+	}
+	var pending []pendingReport
+	problemsByEncoding := map[*_encoding][][]_missingTag{}
+
+	// messages we've already reported, by position, so that we don't report
+	// the same thing twice when a value is both encoded and decoded.
+	reported := map[token.Pos]map[string]bool{}
+	report := func(pos token.Pos, typ types.Type, encoding *_encoding, problems []_missingTag) {
+		if len(problems) == 0 {
+			return
+		}
+
+		paths := make([]string, len(problems))
+		for i, problem := range problems {
+			paths[i] = problem.path
+		}
+		sort.Strings(paths)
+		numToShow := 5
+		if len(paths) > numToShow {
+			suffix := fmt.Sprintf("and %v more", len(paths)-numToShow)
+			paths = append(paths[:numToShow], suffix)
+		}
+
+		message := fmt.Sprintf(
+			"%v type %v lacks explicit struct tags; "+
+				"add explicit struct tags to avoid surprises. "+
+				"see `go doc dev/linters.JSONTagAnalyzer` for more. "+
+				"fields missing tags: %s",
+			encoding.description, typ, strings.Join(paths, ", "))
+		if reported[pos][message] {
+			return
+		}
+		if reported[pos] == nil {
+			reported[pos] = map[string]bool{}
+		}
+		reported[pos][message] = true
+
+		pending = append(pending, pendingReport{
+			diagnostic: analysis.Diagnostic{Pos: pos, Message: message},
+			encoding:   encoding,
+			problems:   problems,
+		})
+		problemsByEncoding[encoding] = append(problemsByEncoding[encoding], problems)
+	}
+
+	// The named types of this package which are JSONified, which we check
+	// (and report) at their declarations, so that each is reported once no
+	// matter how many places JSONify it.
+	var toCheck []_jsonTypeKey
+	checked := map[_jsonTypeKey]bool{}
+	check := func(objs []*types.TypeName, encoding *_encoding, decodes bool) {
+		for _, obj := range objs {
+			key := _jsonTypeKey{obj, encoding, decodes}
+			if !checked[key] {
+				checked[key] = true
+				toCheck = append(toCheck, key)
+			}
+		}
+	}
+	// The problems we found with each of them, which we export as facts.
+	checkedProblems := map[_jsonTypeKey][]_missingTag{}
+
+	type cacheKey struct {
+		typ      types.Type
+		encoding *_encoding
+		decodes  bool
+	}
+	packages := _packagesByPath(pass.Pkg)
+	// map from [type, encoding, direction] to its problems.
+	problemsCache := map[cacheKey][]_missingTag{}
 	for _, val := range _getJSONifiedValues(pass) {
-		cacheKey := [2]types.Type{val.typ, val.marshalInterface}
-		problems, ok := problemsCache[cacheKey]
+		key := cacheKey{val.typ, val.encoding, val.decodes}
+		problems, ok := problemsCache[key]
 		if !ok {
-			analyzer := _typeAnalyzer{
-				marshalInterface: val.marshalInterface,
-				seen:             map[types.Type]bool{},
-			}
+			var local []*types.TypeName
+			analyzer := _newTypeAnalyzer(pass, val.encoding, val.decodes, packages)
+			analyzer.local = &local
 			problems = analyzer.run(val.typ)
-			problemsCache[cacheKey] = problems
+			problemsCache[key] = problems
+			check(local, val.encoding, val.decodes)
 		}
 
-		if len(problems) > 0 {
-			sort.Strings(problems)
-			numToShow := 5
-			if len(problems) > numToShow {
-				suffix := fmt.Sprintf("and %v more", len(problems)-numToShow)
-				problems = append(problems[:numToShow], suffix)
-			}
+		// At the call-site, we only report problems in anonymous types, and
+		// types from other packages which weren't reported there.
+		report(val.node.Pos(), val.typ, val.encoding, problems)
+	}
 
-			pass.Reportf(val.node.Pos(),
-				"JSONified type %v lacks explicit struct tags; "+
-					"add explicit struct tags to avoid surprises. "+
-					"see `go doc dev/linters.JSONTagAnalyzer` for more. "+
-					"fields missing tags: %s",
-				val.typ, strings.Join(problems, ", "))
-		}
+	// Now check the named types at their declarations.  (This may find more
+	// named types to check, in their fields.)
+	for len(toCheck) > 0 {
+		key := toCheck[0]
+		toCheck = toCheck[1:]
+
+		var local []*types.TypeName
+		analyzer := _newTypeAnalyzer(pass, key.encoding, key.decodes, packages)
+		analyzer.local = &local
+		analyzer.root = key.obj.Type()
+		problems := analyzer.run(key.obj.Type())
+		report(key.obj.Pos(), key.obj.Type(), key.encoding, problems)
+		check(local, key.encoding, key.decodes)
+		checkedProblems[key] = problems
+	}
+
+	fields := _fieldsByPos(pass)
+	newTags := _newTagsByField(pass.Pkg, fields, problemsByEncoding)
+	for _, report := range pending {
+		report.diagnostic.SuggestedFixes = _addTagsFixes(
+			pass.Pkg, fields, report.encoding, report.problems, newTags)
+		pass.Report(report.diagnostic)
 	}
 
+	_exportMissingTags(pass, checkedProblems)
+
 	return nil, nil
 }
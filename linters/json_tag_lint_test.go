@@ -0,0 +1,36 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestJSONTagEncodings(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.JSONTagAnalyzer, "jsonformats")
+}
+
+func TestJSONTagFix(t *testing.T) {
+	setFlag(t, linters.JSONTagAnalyzer, "naming", "snake")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.JSONTagAnalyzer, "jsonfix")
+}
+
+func TestJSONTagFixCamel(t *testing.T) {
+	setFlag(t, linters.JSONTagAnalyzer, "naming", "camel")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.JSONTagAnalyzer, "jsonfixcamel")
+}
+
+func TestJSONTagTextMarshalerAndTaggedStructs(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.JSONTagAnalyzer, "jsontagged")
+}
+
+func TestJSONTagFacts(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.JSONTagAnalyzer, "jsondep", "jsonuse")
+}
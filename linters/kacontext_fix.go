@@ -0,0 +1,258 @@
+package linters
+
+// This file contains the suggested fixes for the context-parameter rules of
+// KAContextAnalyzer.
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _renameContextFixes returns a fix which renames the given context parameter
+// of the given function to ctx, along with all its uses.
+//
+// If the function already refers to something called ctx (for example a
+// local variable, or a package-level one), renaming might change what some
+// identifier refers to, so we don't offer a fix.
+func _renameContextFixes(
+	pass *analysis.Pass,
+	funcDecl *ast.FuncDecl,
+	ident *ast.Ident,
+) []analysis.SuggestedFix {
+	obj := pass.TypesInfo.Defs[ident]
+	if obj == nil {
+		return nil
+	}
+
+	conflict := false
+	edits := []analysis.TextEdit{{Pos: ident.Pos(), End: ident.End(), NewText: []byte("ctx")}}
+	ast.Inspect(funcDecl, func(node ast.Node) bool {
+		id, ok := node.(*ast.Ident)
+		if !ok {
+			return true // recurse
+		}
+		if id.Name == "ctx" {
+			conflict = true
+		}
+		if pass.TypesInfo.Uses[id] == obj {
+			edits = append(edits, analysis.TextEdit{
+				Pos: id.Pos(), End: id.End(), NewText: []byte("ctx"),
+			})
+		}
+
+		return !conflict
+	})
+	if conflict {
+		return nil
+	}
+
+	return []analysis.SuggestedFix{{
+		Message:   "Rename " + ident.Name + " to ctx",
+		TextEdits: edits,
+	}}
+}
+
+// _moveContextFirstFixes returns a fix which moves the context parameter,
+// which is the index'th field of the given function's parameter list, to the
+// front, and similarly reorders the arguments at each call to the function
+// in this package.
+//
+// If the function is exported, the fix can't update callers in other
+// packages; if it's used other than by calling it (say it's passed as a
+// callback), or it's a method some interface in this package needs, the fix
+// would make the code not compile.  In those cases we don't offer one.
+func _moveContextFirstFixes(
+	pass *analysis.Pass,
+	file *ast.File,
+	funcDecl *ast.FuncDecl,
+	index int,
+) []analysis.SuggestedFix {
+	if ast.IsExported(funcDecl.Name.Name) {
+		return nil
+	}
+	obj, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok || _satisfiesInterface(pass, obj) {
+		return nil
+	}
+
+	params := funcDecl.Type.Params.List
+	if len(params[index].Names) > 1 {
+		// e.g. `ctx1, ctx2 context.Context`; we can't move both first.
+		return nil
+	}
+
+	// Reorder the parameters.
+	declFile := &_file{File: pass.Fset.File(file.Pos()), AstFile: file}
+	reordered := append([]*ast.Field{params[index]}, params[:index]...)
+	reordered = append(reordered, params[index+1:]...)
+	texts := make([]string, len(reordered))
+	for i, param := range reordered {
+		text, err := declFile.Range(param.Pos(), param.End())
+		if err != nil {
+			return nil
+		}
+		texts[i] = text
+	}
+	edits := []analysis.TextEdit{{
+		Pos:     params[0].Pos(),
+		End:     params[len(params)-1].End(),
+		NewText: []byte(strings.Join(texts, ", ")),
+	}}
+
+	// Find the index of the context among the arguments, which may differ
+	// from index if some fields declare several parameters (`a, b int`).
+	argIndex := 0
+	for _, param := range params[:index] {
+		if len(param.Names) > 1 {
+			argIndex += len(param.Names)
+		} else {
+			argIndex++
+		}
+	}
+
+	// Now reorder the arguments of each call.
+	for _, callFile := range pass.Files {
+		f := &_file{File: pass.Fset.File(callFile.Pos()), AstFile: callFile}
+		called := map[*ast.Ident]bool{}
+		ok := true
+		ast.Inspect(callFile, func(node ast.Node) bool {
+			if !ok {
+				return false
+			}
+			switch node := node.(type) {
+			case *ast.CallExpr:
+				ident := _calleeIdent(node.Fun)
+				if ident == nil || pass.TypesInfo.Uses[ident] != obj {
+					return true
+				}
+				called[ident] = true
+				// In a method expression call, like T.f(t, ...), the first
+				// argument is the receiver, so the parameters start after it.
+				first := 0
+				if _isMethodExpr(node.Fun, pass.TypesInfo) {
+					first = 1
+				}
+				if len(node.Args) <= first+argIndex {
+					// e.g. f(g()) where g returns several values.
+					ok = false
+
+					return false
+				}
+				arg := node.Args[first+argIndex]
+				text, err := f.Range(arg.Pos(), arg.End())
+				if err != nil {
+					ok = false
+
+					return false
+				}
+				edits = append(edits,
+					analysis.TextEdit{
+						Pos:     node.Args[first].Pos(),
+						End:     node.Args[first].Pos(),
+						NewText: []byte(text + ", "),
+					},
+					analysis.TextEdit{
+						Pos: node.Args[first+argIndex-1].End(),
+						End: arg.End(),
+					})
+			case *ast.Ident:
+				// (We visit the CallExpr before its Fun.)
+				if pass.TypesInfo.Uses[node] == obj && !called[node] {
+					ok = false // used other than by a call
+
+					return false
+				}
+			}
+
+			return true
+		})
+		if !ok {
+			return nil
+		}
+	}
+
+	message := "Move " + _paramName(params[index]) + " to be the first parameter"
+
+	return []analysis.SuggestedFix{{Message: message, TextEdits: edits}}
+}
+
+// _satisfiesInterface returns true if the given method is needed for its
+// receiver type (or a pointer to it) to implement some interface used in this
+// package.
+func _satisfiesInterface(pass *analysis.Pass, method *types.Func) bool {
+	recv := method.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false
+	}
+	recvType := recv.Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+
+	for _, typeAndValue := range pass.TypesInfo.Types {
+		iface, ok := typeAndValue.Type.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for i := 0; i < iface.NumMethods(); i++ {
+			if iface.Method(i).Id() == method.Id() &&
+				(types.Implements(recvType, iface) ||
+					types.Implements(types.NewPointer(recvType), iface)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// _calleeIdent returns the identifier naming the function called by a call
+// with the given Fun, like f in f(...) or x.f(...), or nil if there is none.
+func _calleeIdent(fun ast.Expr) *ast.Ident {
+	for {
+		paren, ok := fun.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		fun = paren.X
+	}
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	default:
+		return nil
+	}
+}
+
+// _paramName returns the name of the given parameter, for use in messages.
+func _paramName(param *ast.Field) string {
+	if len(param.Names) == 0 {
+		return "the context"
+	}
+
+	return param.Names[0].Name
+}
+
+// _isMethodExpr returns true if the given Fun of a call is a method
+// expression, like T.f or (*T).f, whose first argument is the receiver.
+func _isMethodExpr(fun ast.Expr, typesInfo *types.Info) bool {
+	for {
+		paren, ok := fun.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		fun = paren.X
+	}
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	selection, ok := typesInfo.Selections[sel]
+
+	return ok && selection.Kind() == types.MethodExpr
+}
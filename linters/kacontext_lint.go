@@ -7,6 +7,7 @@ package linters
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
@@ -59,122 +60,129 @@ func _badCtxOkWithin(node ast.Node) bool {
 		(funcDecl.Name.Name == "init" || funcDecl.Name.Name == "main")
 }
 
-// _lintContextBackground lints for context.Background() calls.
-func _lintContextBackground(
-	report func(analysis.Diagnostic),
-	file *ast.File,
-	typesInfo *types.Info,
-) {
+// Categories of the diagnostics reported by _lintContextBackground.  The
+// context-todo category may be turned off with -kacontext.context-todo=false
+// (for example in codebases where context.TODO() is a conventional marker
+// for code that is still being converted).
+const (
+	_contextBackgroundCategory = "context-background"
+	_contextTODOCategory       = "context-todo"
+)
+
+// _lintContextTODO is the value of the -kacontext.context-todo flag.
+var _lintContextTODO bool
+
+func init() {
+	KAContextAnalyzer.Flags.BoolVar(&_lintContextTODO, _contextTODOCategory, true,
+		"also report context.TODO() outside tests")
+}
+
+// _lintContextBackground lints for context.Background() (and context.TODO())
+// calls.
+//
+// If there's a context in scope (a variable of context-type, or an
+// *http.Request whose context we can use), we suggest using it instead.
+func _lintContextBackground(pass *analysis.Pass, file *ast.File) {
 	// TODO(benkraft): If we end up with a ton of Inspect-based analyzers,
 	// use x/tools/go/analysis/passes/inspect to make them faster.
 	// TODO(benkraft): Move this into banned-symbol linter -- would need to
 	// add support for allowing it in init/main.
 	ast.Inspect(file, func(node ast.Node) bool {
-		name := lintutil.NameOf(lintutil.ObjectFor(node, typesInfo))
-		if name == "context.Background" {
-			report(analysis.Diagnostic{
-				Pos:     node.Pos(),
-				Message: "do not use context.Background() outside tests",
-			})
-			// The children of the node representing context.Background aren't
-			// independently interesting, so we don't traverse them.  (This
-			// also avoids duplicate errors, since the "Background" also ends
-			// up referring to context.Background.
-			return false
+		var call *ast.CallExpr
+		if callExpr, ok := node.(*ast.CallExpr); ok && len(callExpr.Args) == 0 {
+			call = callExpr
+			node = callExpr.Fun
 		}
 
-		return !_badCtxOkWithin(node) // don't traverse init/main
-	})
-}
-
-// handler functions that receive a `*http.Request` param are allowed
-// to upgrade context.
-func _hasHTTPRequestParam(fields []*ast.Field) bool {
-	for _, param := range fields {
-		sid, ok := param.Type.(*ast.StarExpr)
-		if !ok {
-			continue
-		}
-		sel, ok := sid.X.(*ast.SelectorExpr)
-		if !ok {
-			continue
+		name := lintutil.NameOf(lintutil.ObjectFor(node, pass.TypesInfo))
+		category := _contextBackgroundCategory
+		switch name {
+		case "context.Background":
+		case "context.TODO":
+			if !_lintContextTODO {
+				return false
+			}
+			category = _contextTODOCategory
+		default:
+			return !_badCtxOkWithin(node) // don't traverse init/main
 		}
-		if sel.Sel.Name != "Request" {
-			continue
+
+		diagnostic := analysis.Diagnostic{
+			Pos:      node.Pos(),
+			Category: category,
+			Message:  fmt.Sprintf("do not use %v() outside tests", name),
 		}
-		if id, ok := sel.X.(*ast.Ident); ok && id.Name == "http" {
-			return true
+		if call != nil {
+			if ctx := _inScopeContext(pass, call.Pos()); ctx != "" {
+				diagnostic.Message += fmt.Sprintf("; use %v instead", ctx)
+				diagnostic.SuggestedFixes = []analysis.SuggestedFix{{
+					Message: "Replace with " + ctx,
+					TextEdits: []analysis.TextEdit{{
+						Pos: call.Pos(), End: call.End(), NewText: []byte(ctx),
+					}},
+				}}
+			}
 		}
-	}
-
-	return false
-}
+		pass.Report(diagnostic)
 
-func _isPreSaveMethod(funcDecl *ast.FuncDecl, typesInfo *types.Info) bool {
-	// TODO(jared): We could verify that the receiver inherits from
-	// datastore.BaseModel if we want to...
-	if funcDecl.Recv == nil {
-		return false
-	}
-	if funcDecl.Name.Name != "PreSave" {
-		return false
-	}
-	if len(funcDecl.Type.Results.List) != 1 {
-		return false
-	}
-	if res, ok := funcDecl.Type.Results.List[0].Type.(*ast.Ident); !ok || res.Name != "error" {
-		return false
-	}
-	// ctx context.Context should be the only argument
-	if len(funcDecl.Type.Params.List) != 1 {
+		// The children of the node representing context.Background aren't
+		// independently interesting, so we don't traverse them.  (This
+		// also avoids duplicate errors, since the "Background" also ends
+		// up referring to context.Background.
 		return false
-	}
-	firstArg := funcDecl.Type.Params.List[0]
-
-	return lintutil.TypeIs(typesInfo.TypeOf(firstArg.Type), "context", "Context")
+	})
 }
 
-func _isDataLoader(funcDecl *ast.FuncDecl, typesInfo *types.Info) bool {
-	if funcDecl.Type.Results == nil || len(funcDecl.Type.Results.List) != 1 {
-		return false
-	}
-	slice, ok := typesInfo.TypeOf(funcDecl.Type.Results.List[0].Type).(*types.Slice)
-	if !ok {
-		return false
+// _inScopeContext returns an expression for a context that's in scope at the
+// given position, or "" if there is none.
+//
+// We prefer a context-typed variable named ctx, then any other
+// context-typed variable, then the context of an *http.Request (as in an
+// HTTP handler), always looking in the innermost scope first.  We only look
+// at local variables: a package-level context is probably itself
+// context.Background().
+func _inScopeContext(pass *analysis.Pass, pos token.Pos) string {
+	innermost := pass.Pkg.Scope().Innermost(pos)
+	var contextVar, requestVar string
+	for scope := innermost; scope != nil && scope != pass.Pkg.Scope(); scope = scope.Parent() {
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.Var)
+			if !ok || name == "_" {
+				continue
+			}
+			// Make sure it's declared before pos, and not shadowed.
+			if _, visible := innermost.LookupParent(name, pos); visible != obj {
+				continue
+			}
+
+			switch typ := obj.Type(); {
+			case isContextType(typ):
+				if name == "ctx" {
+					return name
+				}
+				if contextVar == "" {
+					contextVar = name
+				}
+			case _isHTTPRequestPointer(typ):
+				if requestVar == "" {
+					requestVar = name + ".Context()"
+				}
+			}
+		}
 	}
-	ptr, ok := slice.Elem().(*types.Pointer)
-	if !ok {
-		return false
+
+	if contextVar != "" {
+		return contextVar
 	}
 
-	return lintutil.TypeIs(ptr.Elem(), "github.com/graph-gophers/dataloader", "Result")
+	return requestVar
 }
 
-// _lintContextUpgrade lints against using kacontext.Upgrade to create new
-// variables outside of a few specific contexts.
-func _lintContextUpgrade(
-	report func(analysis.Diagnostic),
-	file *ast.File,
-	typesInfo *types.Info,
-) {
-	for _, decl := range file.Decls {
-		funcDecl, ok := decl.(*ast.FuncDecl)
-		if !ok {
-			continue
-		}
-		isAllowedToUpgrade := (_isMain(funcDecl) ||
-			lintutil.IsResolverFunc(funcDecl, typesInfo) ||
-			_hasHTTPRequestParam(funcDecl.Type.Params.List) ||
-			_isPreSaveMethod(funcDecl, typesInfo) ||
-			_isDataLoader(funcDecl, typesInfo))
-		if !isAllowedToUpgrade {
-			_forbidContextUpgrade(report, decl, typesInfo)
-		}
-	}
-}
+// _isHTTPRequestPointer returns true if the given type is *http.Request.
+func _isHTTPRequestPointer(typ types.Type) bool {
+	pointer, ok := typ.(*types.Pointer)
 
-func _isMain(funcDecl *ast.FuncDecl) bool {
-	return funcDecl.Name.Name == "main"
+	return ok && lintutil.TypeIs(pointer.Elem(), "net/http", "Request")
 }
 
 func _forbidContextUpgrade(
@@ -205,9 +213,10 @@ func _forbidContextUpgrade(
 
 	ast.Inspect(node, func(node ast.Node) bool {
 		if lit, ok := node.(*ast.FuncLit); ok {
-			// Nested function literals that take *http.Request as an argument
-			// are allowed to do kacontext.Upgade, so don't recurse into them.
-			if _hasHTTPRequestParam(lit.Type.Params.List) {
+			// Nested function literals that are context-roots (such as
+			// HTTP handlers) are allowed to do kacontext.Upgade, so don't
+			// recurse into them.
+			if _isContextRootLiteral(lit, typesInfo) {
 				return false
 			}
 		}
@@ -216,15 +225,11 @@ func _forbidContextUpgrade(
 			return true
 		}
 
-		// serve.Init(func (ctx kacontext.Base) {}) function literals are
-		// allowed to call kacontext.Upgrade
-		if id, ok := call.Fun.(*ast.Ident); ok {
-			if lintutil.NameOf(lintutil.ObjectFor(id, typesInfo)) ==
-				"github.com/Khan/pkg/web/serve.Init" {
-				// serve.Init function arguments are allowed to use
-				// kacontext.Upgrade, so don't recurse into them.
-				return false
-			}
+		// serve.Init(func (ctx kacontext.Base) {}) function literals (and
+		// similar) are allowed to call kacontext.Upgrade, so don't recurse
+		// into them.
+		if _isContextRootCallee(call, typesInfo) {
+			return false
 		}
 
 		t := typesInfo.TypeOf(call)
@@ -243,25 +248,23 @@ func _forbidContextUpgrade(
 	})
 }
 
-// _lintContextParameter lints for incorrect context parameters.
-func _lintContextParameter(
-	report func(analysis.Diagnostic),
-	file *ast.File,
-	typesInfo *types.Info,
-) {
+// _lintContextParameter lints for incorrect context parameters.  (Its
+// suggested fixes are in kacontext_fix.go.)
+func _lintContextParameter(pass *analysis.Pass, file *ast.File) {
 	for _, decl := range file.Decls {
 		funcDecl, ok := decl.(*ast.FuncDecl)
 		if !ok {
 			continue
 		}
 		for i, param := range funcDecl.Type.Params.List {
-			if !isContextType(typesInfo.TypeOf(param.Type)) {
+			if !isContextType(pass.TypesInfo.TypeOf(param.Type)) {
 				continue
 			}
 			if i != 0 || len(param.Names) > 1 {
-				report(analysis.Diagnostic{
-					Pos:     param.Pos(),
-					Message: "Context should be the first parameter",
+				pass.Report(analysis.Diagnostic{
+					Pos:            param.Pos(),
+					Message:        "Context should be the first parameter",
+					SuggestedFixes: _moveContextFirstFixes(pass, file, funcDecl, i),
 				})
 			}
 			// this happens with a function with an un named paramater like:
@@ -273,9 +276,10 @@ func _lintContextParameter(
 			if name != "ctx" && name != "_" {
 				// This duplicates the check in golint, but we may as well
 				// do it here too.
-				report(analysis.Diagnostic{
-					Pos:     param.Pos(),
-					Message: "Context parameter should be called 'ctx'",
+				pass.Report(analysis.Diagnostic{
+					Pos:            param.Pos(),
+					Message:        "Context parameter should be called 'ctx'",
+					SuggestedFixes: _renameContextFixes(pass, funcDecl, param.Names[0]),
 				})
 			}
 		}
@@ -374,7 +378,7 @@ func _lintContextVars(
 
 func _runContext(pass *analysis.Pass) (interface{}, error) {
 	for _, file := range pass.Files {
-		_lintContextParameter(pass.Report, file, pass.TypesInfo)
+		_lintContextParameter(pass, file)
 		filename := pass.Fset.File(file.Pos()).Name()
 		if strings.HasSuffix(filename, "_test.go") {
 			// We allow tests to use context.Background().
@@ -385,10 +389,13 @@ func _runContext(pass *analysis.Pass) (interface{}, error) {
 			continue
 		}
 		if !strings.HasSuffix(filename, "/main.go") {
-			_lintContextUpgrade(pass.Report, file, pass.TypesInfo)
+			err := _lintContextUpgrade(pass, file)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		_lintContextBackground(pass.Report, file, pass.TypesInfo)
+		_lintContextBackground(pass, file)
 		_lintNilContext(pass.Report, file, pass.TypesInfo)
 		_lintContextVars(pass.Report, file, pass.TypesInfo)
 	}
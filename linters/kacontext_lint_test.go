@@ -0,0 +1,35 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestKAContextParameters(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextAnalyzer, "kactxparams")
+}
+
+func TestKAContextBackground(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextAnalyzer, "kactxbackground")
+}
+
+func TestKAContextTODODisabled(t *testing.T) {
+	setFlag(t, linters.KAContextAnalyzer, "context-todo", "false")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextAnalyzer, "kactxtodo")
+}
+
+func TestKAContextUpgradeAllowed(t *testing.T) {
+	setFlag(t, linters.KAContextAnalyzer, "upgrade-allowed",
+		`^\(\*kactxupgrade\.Server\)\.Handle`)
+	setFlag(t, linters.KAContextAnalyzer, "context-root",
+		"anyparam=*kactxupgrade.Job,literals=true")
+	setFlag(t, linters.KAContextAnalyzer, "context-root-callees", "kactxupgrade.register")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.KAContextAnalyzer, "kactxupgrade")
+}
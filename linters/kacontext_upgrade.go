@@ -0,0 +1,398 @@
+package linters
+
+// This file defines where KAContextAnalyzer allows kacontext.Upgrade (or
+// anything else producing the kacontext "everything-type"): only in functions
+// that are the root of a request (or similar), where there's no caller that
+// could have passed in a narrower context.
+//
+// Such a "context root" is any function which:
+//   - matches one of the rules in _contextRoots (main, resolvers, HTTP
+//     handlers, and so on) or the repeated -kacontext.context-root flag, or
+//   - is annotated with a //ka:context-root directive, or
+//   - has a full name (as in types.Func.FullName, e.g. "pkg/path.Func" or
+//     "(*pkg/path.Type).Method") matching one of the regexps in the
+//     comma-separated -kacontext.upgrade-allowed flag,
+// as well as any function literal which matches one of the signature-based
+// rules which apply to literals (such as HTTP handlers), or which is passed
+// to one of the -kacontext.context-root-callees (by default serve.Init).
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// A _signatureMatcher matches functions by their signature.  Types are
+// written as by types.TypeString with no qualifier, e.g. "*net/http.Request";
+// see _typeMatches.
+type _signatureMatcher struct {
+	// method, if set, means the function must be a method.
+	method bool
+	// params, if non-nil, are the exact types of the parameters.
+	params []string
+	// anyParam, if set, is a type that one of the parameters must have.
+	anyParam string
+	// results, if non-nil, are the exact types of the results.
+	results []string
+}
+
+func (m *_signatureMatcher) matches(sig *types.Signature) bool {
+	if m.method && sig.Recv() == nil {
+		return false
+	}
+	if m.params != nil && !_tupleIs(sig.Params(), m.params) {
+		return false
+	}
+	if m.results != nil && !_tupleIs(sig.Results(), m.results) {
+		return false
+	}
+	if m.anyParam != "" {
+		for i := 0; i < sig.Params().Len(); i++ {
+			if _typeMatches(sig.Params().At(i).Type(), m.anyParam) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// _tupleIs returns true if the given tuple has exactly the given types.
+func _tupleIs(tuple *types.Tuple, typeStrings []string) bool {
+	if tuple.Len() != len(typeStrings) {
+		return false
+	}
+	for i, typeString := range typeStrings {
+		if !_typeMatches(tuple.At(i).Type(), typeString) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// _typeMatches returns true if typ is the type written typeString, which may
+// be a named type ("pkg/path.Name", or "Name" for a predeclared type), or a
+// pointer ("*T") or slice ("[]T") of one.
+//
+// We compare named types by their package and name (as lintutil.TypeIs
+// does), not by how they're written, so aliases match the type they stand
+// for, and a vendored copy of a package matches its import-path.
+func _typeMatches(typ types.Type, typeString string) bool {
+	typ = types.Unalias(typ)
+	switch {
+	case strings.HasPrefix(typeString, "*"):
+		ptr, ok := typ.(*types.Pointer)
+
+		return ok && _typeMatches(ptr.Elem(), typeString[1:])
+	case strings.HasPrefix(typeString, "[]"):
+		slice, ok := typ.(*types.Slice)
+
+		return ok && _typeMatches(slice.Elem(), typeString[2:])
+	}
+
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	pkgPath, name := "", typeString
+	if strings.Contains(typeString, ".") {
+		pkgPath, name = _splitTypeName(typeString)
+	}
+	if named.Obj().Name() != name {
+		return false
+	}
+	if named.Obj().Pkg() == nil {
+		return pkgPath == ""
+	}
+
+	return _unvendoredPath(named.Obj().Pkg().Path()) == pkgPath
+}
+
+// _unvendoredPath returns the import-path of the package with the given path,
+// which may be a vendored copy like "example.com/app/vendor/pkg/path".
+func _unvendoredPath(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i != -1 {
+		return path[i+len("/vendor/"):]
+	}
+
+	return strings.TrimPrefix(path, "vendor/")
+}
+
+// A _contextRoot is a rule describing functions which may upgrade context.
+// A function matches if it matches all the non-empty criteria.
+type _contextRoot struct {
+	// description describes the functions, for documentation.
+	description string
+	// name, if set, must match the function's (unqualified) name.
+	name *regexp.Regexp
+	// signature, if set, must match the function's signature.
+	signature *_signatureMatcher
+	// match, if set, must return true for the function's declaration.
+	match func(funcDecl *ast.FuncDecl, typesInfo *types.Info) bool
+	// literals, if set, means function literals matching the signature (the
+	// rule must have no other criteria) may also upgrade context.
+	literals bool
+}
+
+// _contextRootList implements flag.Value for the (repeated)
+// -kacontext.context-root flag, each of which adds a rule like
+// "anyparam=*pkg/path.Request,literals=true" or
+// "name=^Batch,results=[]*pkg/path.Result".
+type _contextRootList []_contextRoot
+
+func (l *_contextRootList) String() string {
+	parts := make([]string, len(*l))
+	for i, root := range *l {
+		parts[i] = root.description
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (l *_contextRootList) Set(value string) error {
+	root := _contextRoot{description: value, signature: &_signatureMatcher{}}
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return fmt.Errorf("invalid context-root %q: %q must be KEY=VALUE", value, part)
+		}
+		var err error
+		switch key, val := kv[0], kv[1]; key {
+		case "name":
+			root.name, err = regexp.Compile(val)
+		case "method":
+			root.signature.method, err = strconv.ParseBool(val)
+		case "params":
+			root.signature.params = strings.Split(val, "|")
+		case "anyparam":
+			root.signature.anyParam = val
+		case "results":
+			root.signature.results = strings.Split(val, "|")
+		case "literals":
+			root.literals, err = strconv.ParseBool(val)
+		default:
+			return fmt.Errorf("invalid context-root %q: unknown key %q", value, key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid context-root %q: %w", value, err)
+		}
+	}
+	if root.literals && root.name != nil {
+		return fmt.Errorf("invalid context-root %q: literals have no name", value)
+	}
+
+	*l = append(*l, root)
+
+	return nil
+}
+
+// _contextRoots are the functions which are allowed to upgrade context.
+var _contextRoots = []_contextRoot{
+	{
+		description: "main",
+		name:        regexp.MustCompile(`^main$`),
+	},
+	{
+		description: "GraphQL resolvers",
+		match:       lintutil.IsResolverFunc,
+	},
+	{
+		description: "HTTP handlers",
+		signature:   &_signatureMatcher{anyParam: "*net/http.Request"},
+		literals:    true,
+	},
+	{
+		// TODO(jared): We could verify that the receiver inherits from
+		// datastore.BaseModel if we want to...
+		description: "datastore PreSave methods",
+		name:        regexp.MustCompile(`^PreSave$`),
+		signature: &_signatureMatcher{
+			method:  true,
+			params:  []string{"context.Context"},
+			results: []string{"error"},
+		},
+	},
+	{
+		description: "dataloader batch functions",
+		signature: &_signatureMatcher{
+			results: []string{"[]*github.com/graph-gophers/dataloader.Result"},
+		},
+	},
+}
+
+// _contextRootCallees are functions whose function-literal arguments may
+// upgrade context, by their full names (as in lintutil.NameOf).
+var _contextRootCallees = _funcNameList{
+	"github.com/Khan/pkg/web/serve.Init",
+}
+
+// _extraContextRoots are the rules added by the -kacontext.context-root flag.
+var _extraContextRoots _contextRootList
+
+// _allContextRoots returns the builtin rules and those added by flags.
+func _allContextRoots() []_contextRoot {
+	retval := make([]_contextRoot, 0, len(_contextRoots)+len(_extraContextRoots))
+
+	return append(append(retval, _contextRoots...), _extraContextRoots...)
+}
+
+// _funcNameList is a list of function names.  It implements flag.Value, as a
+// comma-separated list.
+type _funcNameList []string
+
+func (l *_funcNameList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *_funcNameList) Set(value string) error {
+	*l = nil
+	for _, name := range strings.Split(value, ",") {
+		if name != "" {
+			*l = append(*l, name)
+		}
+	}
+
+	return nil
+}
+
+// _contextRootDirective marks a function as being allowed to upgrade context.
+const _contextRootDirective = "//ka:context-root"
+
+// _isContextRootDirective returns true if the given comment is the
+// //ka:context-root directive (perhaps followed by an explanation).
+func _isContextRootDirective(text string) bool {
+	rest, ok := strings.CutPrefix(text, _contextRootDirective)
+
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// _upgradeAllowed is the value of the -kacontext.upgrade-allowed flag.
+var _upgradeAllowed string
+
+func init() {
+	KAContextAnalyzer.Flags.StringVar(&_upgradeAllowed, "upgrade-allowed", "",
+		"comma-separated regexps matching the full names of additional "+
+			"functions which may use kacontext.Upgrade, "+
+			`e.g. "^\(\*pkg/path\.Server\)\.Handle"`)
+	KAContextAnalyzer.Flags.Var(&_extraContextRoots, "context-root",
+		"name=REGEXP,method=BOOL,params=T1|T2,anyparam=T,results=T1|T2,literals=BOOL: "+
+			"functions (or, with literals=true, function literals) matching all "+
+			"the given criteria may use kacontext.Upgrade (may be repeated)")
+	KAContextAnalyzer.Flags.Var(&_contextRootCallees, "context-root-callees",
+		"comma-separated full names of functions whose function-literal "+
+			"arguments may use kacontext.Upgrade")
+}
+
+// _upgradeAllowedRegexps parses the -kacontext.upgrade-allowed flag.
+func _upgradeAllowedRegexps() ([]*regexp.Regexp, error) {
+	var retval []*regexp.Regexp
+	for _, pattern := range strings.Split(_upgradeAllowed, ",") {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -kacontext.upgrade-allowed pattern %q: %w", pattern, err)
+		}
+		retval = append(retval, re)
+	}
+
+	return retval, nil
+}
+
+// _isContextRoot returns true if the given function declaration is allowed to
+// upgrade context.
+func _isContextRoot(
+	funcDecl *ast.FuncDecl,
+	typesInfo *types.Info,
+	allowed []*regexp.Regexp,
+) bool {
+	if funcDecl.Doc != nil {
+		for _, comment := range funcDecl.Doc.List {
+			if _isContextRootDirective(comment.Text) {
+				return true
+			}
+		}
+	}
+
+	fn, ok := typesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	for _, re := range allowed {
+		if re.MatchString(fn.FullName()) {
+			return true
+		}
+	}
+
+	sig, _ := fn.Type().(*types.Signature)
+	for _, root := range _allContextRoots() {
+		if (root.name == nil || root.name.MatchString(funcDecl.Name.Name)) &&
+			(root.signature == nil || (sig != nil && root.signature.matches(sig))) &&
+			(root.match == nil || root.match(funcDecl, typesInfo)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _isContextRootLiteral returns true if the given function literal is allowed
+// to upgrade context by virtue of its signature.
+func _isContextRootLiteral(lit *ast.FuncLit, typesInfo *types.Info) bool {
+	sig, ok := typesInfo.TypeOf(lit).(*types.Signature)
+	if !ok {
+		return false
+	}
+	for _, root := range _allContextRoots() {
+		if root.literals && root.name == nil && root.match == nil &&
+			root.signature != nil && root.signature.matches(sig) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _isContextRootCallee returns true if function-literal arguments to the
+// given call are allowed to upgrade context.
+func _isContextRootCallee(call *ast.CallExpr, typesInfo *types.Info) bool {
+	name := lintutil.NameOf(lintutil.ObjectFor(call.Fun, typesInfo))
+	for _, callee := range _contextRootCallees {
+		if name == callee {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _lintContextUpgrade lints against using kacontext.Upgrade to create new
+// variables outside of a few specific contexts.
+func _lintContextUpgrade(pass *analysis.Pass, file *ast.File) error {
+	allowed, err := _upgradeAllowedRegexps()
+	if err != nil {
+		return err
+	}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if !_isContextRoot(funcDecl, pass.TypesInfo, allowed) {
+			_forbidContextUpgrade(pass.Report, decl, pass.TypesInfo)
+		}
+	}
+
+	return nil
+}
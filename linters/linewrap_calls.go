@@ -0,0 +1,208 @@
+package linters
+
+// This file contains the auto-fixing of long lines containing a function call
+// or composite literal, which we wrap by putting one argument (or element) on
+// each line, with trailing commas, as in
+//	x := myFunction(
+//		arg1,
+//		arg2,
+//	)
+// This is what gofmt expects; for key-value pairs in composite literals we
+// also align the values as gofmt would.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _wrappable is a call or composite literal we know how to wrap.
+type _wrappable struct {
+	open, close token.Pos // the `(` and `)`, or `{` and `}`
+	elems       []ast.Expr
+	ellipsis    token.Pos // the `...` of a variadic call, if any
+}
+
+// _wrappableAt returns the outermost call or composite literal which is
+// entirely on the given line and has at least one argument or element, or
+// nil if there is none.
+func _wrappableAt(file *_file, lineNum int) *_wrappable {
+	var retval *_wrappable
+	ast.Inspect(file.AstFile, func(node ast.Node) bool {
+		if retval != nil || node == nil ||
+			file.Line(node.Pos()) > lineNum || file.Line(node.End()) < lineNum {
+			return false
+		}
+
+		var candidate *_wrappable
+		switch node := node.(type) {
+		case *ast.CallExpr:
+			candidate = &_wrappable{node.Lparen, node.Rparen, node.Args, node.Ellipsis}
+		case *ast.CompositeLit:
+			candidate = &_wrappable{node.Lbrace, node.Rbrace, node.Elts, token.NoPos}
+		}
+		if candidate != nil && len(candidate.elems) > 0 &&
+			file.Line(candidate.open) == lineNum &&
+			file.Line(candidate.close) == lineNum {
+			retval = candidate
+		}
+
+		return retval == nil // recurse until we find one
+	})
+
+	return retval
+}
+
+// _hasCommentBetween returns true if there's a comment in the file between
+// the given positions.
+func _hasCommentBetween(file *_file, start, end token.Pos) bool {
+	for _, commentGroup := range file.AstFile.Comments {
+		if commentGroup.Pos() < end && commentGroup.End() > start {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _wrapLine returns the lines with which to replace the given line to wrap
+// the given call or composite literal, or nil if we can't.
+func _wrapLine(file *_file, lineNum int, line string, wrappable *_wrappable) ([]string, error) {
+	// We can't keep track of comments between the arguments.
+	if _hasCommentBetween(file, wrappable.open, wrappable.close) {
+		return nil, nil
+	}
+
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	firstLine, err := file.Range(file.LineStart(lineNum), wrappable.open+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	newLines := []string{firstLine}
+
+	var elemLines []string
+	hasKeyValue := false
+	for i, elem := range wrappable.elems {
+		end := elem.End()
+		if i == len(wrappable.elems)-1 && wrappable.ellipsis.IsValid() {
+			end = wrappable.ellipsis + token.Pos(len("..."))
+		}
+		elemText, err := file.Range(elem.Pos(), end)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		elemLines = append(elemLines, "\t"+elemText+",")
+		if _, ok := elem.(*ast.KeyValueExpr); ok {
+			hasKeyValue = true
+		}
+	}
+	if hasKeyValue {
+		elemLines, err = _alignKeyValues(elemLines)
+		if err != nil || elemLines == nil {
+			return nil, err
+		}
+	}
+	for _, elemLine := range elemLines {
+		newLines = append(newLines, indent+elemLine)
+	}
+
+	// Everything from the closing paren/brace to the end of the line goes on
+	// the last line.
+	lineEnd, err := file.LineEnd(lineNum)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	lastLine, err := file.Range(wrappable.close, lineEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	newLines = append(newLines, indent+lastLine)
+
+	return newLines, nil
+}
+
+// _alignKeyValues aligns the values of the given lines, each a tab-indented
+// element of a composite literal, some of them key-value pairs, as gofmt
+// would.  The alignment depends on the keys in ways that are hard to
+// replicate, so we just let gofmt do it.  It returns nil if gofmt doesn't
+// give back one line per element.
+func _alignKeyValues(elemLines []string) ([]string, error) {
+	src := "_ = x{\n" + strings.Join(elemLines, "\n") + "\n}\n"
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(formatted), "\n"), "\n")
+	if len(lines) != len(elemLines)+2 {
+		return nil, nil
+	}
+
+	return lines[1 : len(lines)-1], nil
+}
+
+// _getCallIssuesForFile reports long lines containing a call or composite
+// literal, with a fix to wrap them.  It updates lintedLines in place.
+func _getCallIssuesForFile(
+	file *_file,
+	maxLineLen, tabSpaces int,
+	lintedLines map[int]bool,
+) ([]analysis.Diagnostic, error) {
+	var diagnostics []analysis.Diagnostic
+
+	numLines, err := file.NumLines()
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	for i := 1; i <= numLines; i++ {
+		if lintedLines[i] {
+			continue
+		}
+
+		line, err := file.LineText(i)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		// We measure lines as in _getLonglineIssuesForFile.
+		if _isLonglineException(line) {
+			continue
+		}
+		measuredLine := line
+		nolintStart := strings.Index(line, " //nolint:")
+		if nolintStart > -1 {
+			measuredLine = line[:nolintStart]
+		}
+		lineLen := _lineLength(measuredLine, tabSpaces)
+		if lineLen <= maxLineLen {
+			continue
+		}
+
+		wrappable := _wrappableAt(file, i)
+		if wrappable == nil {
+			continue // _getLonglineIssuesForFile will report it
+		}
+		newLines, err := _wrapLine(file, i, line, wrappable)
+		if err != nil {
+			return nil, err
+		}
+		if newLines == nil {
+			continue
+		}
+
+		msg := fmt.Sprintf("line is %d characters", lineLen)
+		diagnostic, err := _diagnostic(file, i, i, i, newLines, msg)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		lintedLines[i] = true
+	}
+
+	return diagnostics, nil
+}
@@ -0,0 +1,184 @@
+package linters
+
+// This file contains the configuration for LinewrapAnalyzer, which is set via
+// flags:
+//   -linewrap.comment-max N   the longest comment line allowed (default 79)
+//   -linewrap.code-max N      the longest code line allowed (default 100)
+//   -linewrap.tab-width N     the width we count for each tab (default 4)
+//   -linewrap.override RE=N[:M]
+//       for files whose path matches the regexp RE, allow code lines of up
+//       to N characters (and comment lines of up to M, if given); may be
+//       repeated, in which case the last matching override wins
+//   -linewrap.exception RE
+//       allow (non-comment) lines of any length that match the regexp RE, in
+//       addition to those matching gexceptionsRegexp; may be repeated
+//   -linewrap.split-strings
+//       report long lines ending in a string literal (which we otherwise
+//       allow), with a fix to split the string; see linewrap_strings.go
+//
+// The regexp flags are repeated, rather than comma-separated, because
+// regexps often contain commas (e.g. `\w{1,3}`).
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// _linewrapLimits are the limits we apply to a particular file.
+type _linewrapLimits struct {
+	maxCommentLineLen int
+	maxCodeLineLen    int
+	tabSpaces         int
+}
+
+// _linewrapOverride is the value of a single -linewrap.override flag.
+type _linewrapOverride struct {
+	path              *regexp.Regexp
+	maxCodeLineLen    int
+	maxCommentLineLen int // or 0 to use the default
+}
+
+// _linewrapOverrides implements flag.Value for the (repeated)
+// -linewrap.override flag.
+type _linewrapOverrides []_linewrapOverride
+
+func (o *_linewrapOverrides) String() string {
+	parts := make([]string, len(*o))
+	for i, override := range *o {
+		parts[i] = fmt.Sprintf("%v=%v", override.path, override.maxCodeLineLen)
+		if override.maxCommentLineLen != 0 {
+			parts[i] += fmt.Sprintf(":%v", override.maxCommentLineLen)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (o *_linewrapOverrides) Set(value string) error {
+	// The regexp may itself contain "=", but the limits can't.
+	sep := strings.LastIndex(value, "=")
+	if sep == -1 {
+		return fmt.Errorf("invalid override %q: must be REGEXP=N or REGEXP=N:M", value)
+	}
+	path, err := regexp.Compile(value[:sep])
+	if err != nil {
+		return fmt.Errorf("invalid override %q: %w", value, err)
+	}
+
+	override := _linewrapOverride{path: path}
+	limits := strings.SplitN(value[sep+1:], ":", 2)
+	override.maxCodeLineLen, err = strconv.Atoi(limits[0])
+	if err == nil && len(limits) > 1 {
+		override.maxCommentLineLen, err = strconv.Atoi(limits[1])
+	}
+	if err != nil {
+		return fmt.Errorf("invalid override %q: %w", value, err)
+	}
+	if override.maxCodeLineLen <= 0 || override.maxCommentLineLen < 0 {
+		return fmt.Errorf("invalid override %q: limits must be positive", value)
+	}
+
+	*o = append(*o, override)
+
+	return nil
+}
+
+// _regexpList implements flag.Value for a repeated regexp flag.
+type _regexpList []*regexp.Regexp
+
+func (l *_regexpList) String() string {
+	parts := make([]string, len(*l))
+	for i, re := range *l {
+		parts[i] = re.String()
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (l *_regexpList) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return fmt.Errorf("invalid regexp %q: %w", value, err)
+	}
+	*l = append(*l, re)
+
+	return nil
+}
+
+func (l *_regexpList) matches(s string) bool {
+	for _, re := range *l {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	_linewrapDefaults = _linewrapLimits{
+		maxCommentLineLen: gmaxCommentLineLen,
+		maxCodeLineLen:    gmaxCodeLineLen,
+		tabSpaces:         gtabSpaces,
+	}
+	_linewrapPathOverrides _linewrapOverrides
+	_linewrapExceptions    _regexpList
+	_linewrapSplitStrings  bool
+)
+
+func init() {
+	LinewrapAnalyzer.Flags.IntVar(&_linewrapDefaults.maxCommentLineLen, "comment-max",
+		gmaxCommentLineLen, "maximum length of comment lines")
+	LinewrapAnalyzer.Flags.IntVar(&_linewrapDefaults.maxCodeLineLen, "code-max",
+		gmaxCodeLineLen, "maximum length of code lines")
+	LinewrapAnalyzer.Flags.IntVar(&_linewrapDefaults.tabSpaces, "tab-width",
+		gtabSpaces, "number of characters to count for each tab")
+	LinewrapAnalyzer.Flags.Var(&_linewrapPathOverrides, "override",
+		"REGEXP=N or REGEXP=N:M: use limits of N for code (and M for comments) "+
+			"in files whose path matches REGEXP (may be repeated)")
+	LinewrapAnalyzer.Flags.Var(&_linewrapExceptions, "exception",
+		"allow code lines of any length that match this regexp (may be repeated)")
+	LinewrapAnalyzer.Flags.BoolVar(&_linewrapSplitStrings, "split-strings", false,
+		"report long string literals, with a fix to split them")
+}
+
+// _linewrapLimitsFor returns the limits to apply to the file with the given
+// name.
+func _linewrapLimitsFor(filename string) _linewrapLimits {
+	limits := _linewrapDefaults
+	filename = filepath.ToSlash(filename)
+	for _, override := range _linewrapPathOverrides {
+		if !override.path.MatchString(filename) {
+			continue
+		}
+		limits.maxCodeLineLen = override.maxCodeLineLen
+		limits.maxCommentLineLen = _linewrapDefaults.maxCommentLineLen
+		if override.maxCommentLineLen != 0 {
+			limits.maxCommentLineLen = override.maxCommentLineLen
+		}
+	}
+
+	return limits
+}
+
+// _validateLinewrapLimits returns an error if the limits set by flags are
+// invalid.
+func _validateLinewrapLimits() error {
+	if _linewrapDefaults.maxCommentLineLen <= 0 ||
+		_linewrapDefaults.maxCodeLineLen <= 0 ||
+		_linewrapDefaults.tabSpaces <= 0 {
+		return fmt.Errorf(
+			"invalid -linewrap flags: comment-max, code-max, and tab-width must be positive")
+	}
+
+	return nil
+}
+
+// _isLonglineException returns true if the given (non-comment) line may be
+// arbitrarily long.
+func _isLonglineException(line string) bool {
+	return gexceptionsRegexp.MatchString(line) || _linewrapExceptions.matches(line)
+}
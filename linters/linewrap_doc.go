@@ -0,0 +1,162 @@
+package linters
+
+// This file contains the logic to split `//`-comments into blocks we can
+// reflow, following the Go doc-comment syntax (see
+// https://go.dev/doc/comment):
+//   - A heading (`# Heading`, on its own between blank lines) and a link
+//     definition (`[Text]: URL`) are each a block we never reflow.
+//   - A span of indented lines is a list if its first line starts with a
+//     list marker (`-`, `*`, `+`, `•`, or a number followed by `.` or `)`),
+//     in which case each item is a block, reflowed with hanging indentation
+//     like this one; or a code block otherwise, which we never reflow.
+//   - A span of unindented lines is a paragraph, which we split up into
+//     blocks as we always have; see _shareCommentBlock.
+// (We don't use go/doc/comment itself since it doesn't tell us which lines
+// each block came from.)
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A _commentBlock is a block of lines in a comment-group which we reflow
+// together (or not at all).
+type _commentBlock struct {
+	start int // the index of the first line in the comment-group
+	lines []string
+	// reflow is false if we must never reflow this block (e.g. code).
+	reflow bool
+	// hanging, if set, is the prefix for all but the first line of the
+	// block when we reflow it (otherwise, all lines get the prefix of the
+	// first line).
+	hanging string
+}
+
+// _listMarker matches the start of a list item, after the indentation.
+var _listMarker = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])[ \t]+`)
+
+// _linkDefComment matches a link definition, e.g. `// [Text]: https://...`.
+var _linkDefComment = regexp.MustCompile(`^\s*//\s*\[[^\[\]]+\]:\s*\S+\s*$`)
+
+// _docCommentText returns the text of a `//`-comment line, after the `//`
+// and a single space, or ok=false if it's not a `//`-comment line.
+func _docCommentText(line string) (text string, ok bool) {
+	text = strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(text, "//") {
+		return "", false
+	}
+	text = strings.TrimPrefix(text[len("//"):], " ")
+
+	return text, true
+}
+
+func _isIndented(text string) bool {
+	return strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")
+}
+
+// _paragraphBlocks splits up the given lines, starting at the given index in
+// their comment-group, into blocks according to _shareCommentBlock.
+func _paragraphBlocks(lines []string, start int) []_commentBlock {
+	var blocks []_commentBlock
+	for i, line := range lines {
+		if i == 0 || !_shareCommentBlock(line, blocks[len(blocks)-1].lines[0]) {
+			blocks = append(blocks, _commentBlock{start: start + i, reflow: true})
+		}
+		blocks[len(blocks)-1].lines = append(blocks[len(blocks)-1].lines, line)
+	}
+
+	return blocks
+}
+
+// _listItemBlock returns the block for a list item starting with the given
+// line, with hanging indentation to line up with the text after the marker.
+func _listItemBlock(line string, start int) _commentBlock {
+	prefix := _commentPrefix(line)
+	marker := _listMarker.FindString(line[len(prefix):])
+
+	return _commentBlock{
+		start:   start,
+		lines:   []string{line},
+		reflow:  true,
+		hanging: prefix + strings.Repeat(" ", utf8.RuneCountInString(marker)),
+	}
+}
+
+// _commentBlocks splits up the given comment-group lines into blocks.
+func _commentBlocks(lines []string) []_commentBlock {
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		text, ok := _docCommentText(line)
+		if !ok {
+			// A C-style comment: the doc-comment syntax doesn't apply.
+			return _paragraphBlocks(lines, 0)
+		}
+		texts[i] = text
+	}
+	isBlank := func(i int) bool {
+		return i < 0 || i >= len(texts) || strings.TrimSpace(texts[i]) == ""
+	}
+	verbatim := func(i int) _commentBlock {
+		return _commentBlock{start: i, lines: lines[i : i+1]}
+	}
+
+	var blocks []_commentBlock
+	for i := 0; i < len(lines); {
+		switch {
+		case isBlank(i):
+			blocks = append(blocks, verbatim(i))
+			i++
+
+		case _isIndented(texts[i]):
+			// A list or code block, which continues through any indented or
+			// blank lines (but doesn't include trailing blank lines).
+			end := i + 1
+			for j := i + 1; j < len(lines) && (isBlank(j) || _isIndented(texts[j])); j++ {
+				if !isBlank(j) {
+					end = j + 1
+				}
+			}
+
+			isList := _listMarker.MatchString(strings.TrimLeft(texts[i], " \t"))
+			for ; i < end; i++ {
+				text := strings.TrimLeft(texts[i], " \t")
+				switch {
+				case !isList || isBlank(i):
+					blocks = append(blocks, verbatim(i))
+				case _listMarker.MatchString(text):
+					blocks = append(blocks, _listItemBlock(lines[i], i))
+				case !blocks[len(blocks)-1].reflow:
+					// A continuation after a blank line: we treat it as its
+					// own item, without a marker.
+					blocks = append(blocks, _commentBlock{
+						start: i, lines: []string{lines[i]}, reflow: true,
+					})
+				default: // a continuation of the current item
+					blocks[len(blocks)-1].lines = append(blocks[len(blocks)-1].lines, lines[i])
+				}
+			}
+
+		case strings.HasPrefix(texts[i], "# ") && isBlank(i-1) && isBlank(i+1):
+			blocks = append(blocks, verbatim(i)) // a heading
+			i++
+
+		case _linkDefComment.MatchString(lines[i]):
+			blocks = append(blocks, verbatim(i))
+			i++
+
+		default:
+			// A paragraph, which continues through any unindented lines
+			// other than link definitions.
+			end := i + 1
+			for end < len(lines) && !isBlank(end) && !_isIndented(texts[end]) &&
+				!_linkDefComment.MatchString(lines[end]) {
+				end++
+			}
+			blocks = append(blocks, _paragraphBlocks(lines[i:end], i)...)
+			i = end
+		}
+	}
+
+	return blocks
+}
@@ -0,0 +1,392 @@
+package linters
+
+// This file contains the auto-fixing of long function declarations.  We wrap
+// the parameters, then the results, then a body that's on the same line, one
+// per line, until each line fits; for example
+//	func (r *myReceiver) MyFunction(
+//		ctx context.Context, // the context
+//		arg1, arg2 string,
+//	) (
+//		result int,
+//		err error,
+//	) {
+// Once a list is wrapped we leave it that way, so that the fix is stable:
+// neither gofmt nor running the fix again will change it further.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _fieldList is a parameter or result list, ready to be rewritten.
+type _fieldList struct {
+	// The text of each field, and of the comments after it (before the next
+	// field), if any.
+	fields   []string
+	comments []string
+	// The text of the comments before the first field, if any.
+	leading string
+	// Whether the list is currently on multiple lines.
+	wrapped bool
+}
+
+// _commentsBetween returns the text of the comments in the file between the
+// given positions, joined by spaces.
+func _commentsBetween(file *_file, start, end token.Pos) string {
+	var texts []string
+	for _, commentGroup := range file.AstFile.Comments {
+		for _, comment := range commentGroup.List {
+			if start <= comment.Pos() && comment.End() <= end {
+				texts = append(texts, comment.Text)
+			}
+		}
+	}
+
+	return strings.Join(texts, " ")
+}
+
+// _withComments returns text followed by the given comments, if any.
+func _withComments(text, comments string) string {
+	if comments == "" {
+		return text
+	}
+
+	return text + " " + comments
+}
+
+// _newFieldList returns the _fieldList for a parenthesized parameter or result
+// list, or nil if we can't rewrite it (because a field spans multiple lines).
+func _newFieldList(file *_file, list *ast.FieldList) (*_fieldList, error) {
+	retval := &_fieldList{
+		wrapped: file.Line(list.Opening) != file.Line(list.Closing),
+	}
+	if len(list.List) == 0 {
+		return retval, nil
+	}
+
+	retval.leading = _commentsBetween(file, list.Opening+1, list.List[0].Pos())
+	for i, field := range list.List {
+		text, err := file.Range(field.Pos(), field.End())
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		if strings.Contains(text, "\n") {
+			return nil, nil
+		}
+
+		next := list.Closing
+		if i+1 < len(list.List) {
+			next = list.List[i+1].Pos()
+		}
+		retval.fields = append(retval.fields, text)
+		retval.comments = append(retval.comments, _commentsBetween(file, field.End(), next))
+	}
+
+	return retval, nil
+}
+
+// hasComments returns true if the list has any comments (outside its
+// fields), meaning it must be wrapped.
+func (l *_fieldList) hasComments() bool {
+	if l.leading != "" {
+		return true
+	}
+	for _, comments := range l.comments {
+		if comments != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write appends the list, and its closing paren, to lines, whose last line
+// ends with the opening paren.  If wrap is set, it puts each field on its own
+// line.  (Unlike for struct fields, gofmt doesn't align the comments.)
+func (l *_fieldList) write(lines []string, wrap bool) []string {
+	if !wrap {
+		lines[len(lines)-1] += strings.Join(l.fields, ", ") + ")"
+
+		return lines
+	}
+
+	lines[len(lines)-1] = _withComments(lines[len(lines)-1], l.leading)
+	for i, field := range l.fields {
+		lines = append(lines, _withComments("\t"+field+",", l.comments[i]))
+	}
+
+	return append(lines, ")")
+}
+
+// _funcDeclWrapper knows how to rewrite a function declaration with its
+// parameters, results, and body each wrapped or not.
+type _funcDeclWrapper struct {
+	prefix  string // everything through the `(` of the parameters
+	params  *_fieldList
+	results *_fieldList // nil if there are no parenthesized results
+	// The text of the results (with leading space), if not parenthesized.
+	result string
+	// The text of the single-line body's statements (nil if the body is on
+	// multiple lines, or empty), or of the text from the `{` (or end of the
+	// results, if there's no body) to the end of the line otherwise.
+	stmts []string
+	tail  string
+	// The text after the `}` of a single-line body, if any.
+	afterBody string
+}
+
+// _newFuncDeclWrapper returns a _funcDeclWrapper for the given declaration,
+// whose last line is the given line, or nil if we can't rewrite it.
+func _newFuncDeclWrapper(
+	file *_file,
+	funcDecl *ast.FuncDecl,
+	endLine int,
+) (*_funcDeclWrapper, error) {
+	funcType := funcDecl.Type
+	retval := &_funcDeclWrapper{}
+
+	// We can't keep track of comments except in the parameter and result
+	// lists.
+	var err error
+	retval.prefix, err = file.Range(funcDecl.Pos(), funcType.Params.Opening+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	if strings.Contains(retval.prefix, "\n") ||
+		_hasCommentBetween(file, funcDecl.Pos(), funcType.Params.Opening) {
+		return nil, nil
+	}
+
+	retval.params, err = _newFieldList(file, funcType.Params)
+	if retval.params == nil || err != nil {
+		return nil, err
+	}
+
+	headerEnd := funcType.Params.End()
+	results := funcType.Results
+	switch {
+	case results == nil:
+	case results.Opening.IsValid():
+		retval.results, err = _newFieldList(file, results)
+		if retval.results == nil || err != nil {
+			return nil, err
+		}
+		if _hasCommentBetween(file, headerEnd, results.Opening) {
+			return nil, nil
+		}
+		headerEnd = results.End()
+	default:
+		retval.result, err = file.Range(headerEnd, results.End())
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		if strings.Contains(retval.result, "\n") ||
+			_hasCommentBetween(file, headerEnd, results.End()) {
+			return nil, nil
+		}
+		headerEnd = results.End()
+	}
+
+	lineEnd, err := file.LineEnd(endLine)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	body := funcDecl.Body
+	if body == nil { // e.g. a function implemented in assembly
+		if headerEnd < lineEnd {
+			retval.tail, err = file.Range(headerEnd, lineEnd)
+			if err != nil {
+				return nil, fmt.Errorf("%w", err)
+			}
+		}
+
+		return retval, nil
+	}
+
+	if _hasCommentBetween(file, headerEnd, body.Lbrace) {
+		return nil, nil
+	}
+	retval.tail, err = file.Range(body.Lbrace, lineEnd)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	retval.tail = " " + retval.tail
+
+	if file.Line(body.Lbrace) != file.Line(body.Rbrace) || len(body.List) == 0 ||
+		_hasCommentBetween(file, body.Lbrace, body.Rbrace) {
+		return retval, nil
+	}
+	for _, stmt := range body.List {
+		text, err := file.Range(stmt.Pos(), stmt.End())
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		retval.stmts = append(retval.stmts, text)
+	}
+	if body.Rbrace+1 < lineEnd {
+		retval.afterBody, err = file.Range(body.Rbrace+1, lineEnd)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+	}
+
+	return retval, nil
+}
+
+// lines returns the lines of the declaration with the parameters, results,
+// and body wrapped as requested.
+func (w *_funcDeclWrapper) lines(wrapParams, wrapResults, wrapBody bool) []string {
+	lines := w.params.write([]string{w.prefix}, wrapParams)
+	if w.results != nil {
+		lines[len(lines)-1] += " ("
+		lines = w.results.write(lines, wrapResults)
+	} else {
+		lines[len(lines)-1] += w.result
+	}
+
+	if !wrapBody {
+		lines[len(lines)-1] += w.tail
+
+		return lines
+	}
+	lines[len(lines)-1] += " {"
+	for _, stmt := range w.stmts {
+		lines = append(lines, "\t"+stmt)
+	}
+
+	return append(lines, "}"+w.afterBody)
+}
+
+// candidates returns the ways we could wrap the declaration, in order of
+// preference.  Lists that are already wrapped, or that have comments, are
+// always wrapped.
+func (w *_funcDeclWrapper) candidates() [][]string {
+	var retval [][]string
+	for _, wrap := range [][3]bool{
+		{false, false, false},
+		{false, false, true},
+		{true, false, false},
+		{true, false, true},
+		{true, true, false},
+		{true, true, true},
+	} {
+		wrapParams := len(w.params.fields) > 0 &&
+			(wrap[0] || w.params.wrapped || w.params.hasComments())
+		wrapResults := w.results != nil && len(w.results.fields) > 0 &&
+			(wrap[1] || w.results.wrapped || w.results.hasComments())
+		wrapBody := wrap[2] && w.stmts != nil
+		retval = append(retval, w.lines(wrapParams, wrapResults, wrapBody))
+	}
+
+	return retval
+}
+
+// _longestLine returns the index and length of the longest of the given
+// lines.
+func _longestLine(lines []string, tabSpaces int) (index, length int) {
+	for i, line := range lines {
+		if lineLen := _lineLength(line, tabSpaces); lineLen > length {
+			index, length = i, lineLen
+		}
+	}
+
+	return index, length
+}
+
+// _getFuncIssue returns the line-numbers of the lines of this funcDecl's
+// declaration if we successfully linted them (they were short enough, or we
+// reported them), or nil if not.
+func _getFuncIssue(
+	file *_file,
+	funcDecl *ast.FuncDecl,
+	maxLineLen, tabSpaces int,
+) (diagnostics []analysis.Diagnostic, lintedLines []int, err error) {
+	startLine := file.Line(funcDecl.Pos())
+	// This is the end of the decl: the line with the `{` that starts the body
+	// (which is also that with the `}` that ends it, if it's on one line).
+	endLine := file.Line(funcDecl.End())
+	if funcDecl.Body != nil {
+		endLine = file.Line(funcDecl.Body.Lbrace)
+	}
+
+	var oldLines []string
+	for i := startLine; i <= endLine; i++ {
+		line, err := file.LineText(i)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w", err)
+		}
+		oldLines = append(oldLines, line)
+		lintedLines = append(lintedLines, i)
+	}
+
+	longest, lineLen := _longestLine(oldLines, tabSpaces)
+	if lineLen <= maxLineLen {
+		return nil, lintedLines, nil
+	}
+
+	wrapper, err := _newFuncDeclWrapper(file, funcDecl, endLine)
+	if wrapper == nil || err != nil {
+		// Return nil so these lines will get linted again (normally) later.
+		return nil, nil, err
+	}
+
+	// Use the first candidate which fits, or else the last, which wraps as
+	// much as we can.
+	candidates := wrapper.candidates()
+	newLines := candidates[len(candidates)-1]
+	for _, candidate := range candidates {
+		if _, candidateLen := _longestLine(candidate, tabSpaces); candidateLen <= maxLineLen {
+			newLines = candidate
+
+			break
+		}
+	}
+	if strings.Join(newLines, "\n") == strings.Join(oldLines, "\n") {
+		// We can't do any better than what's there.
+		return nil, nil, nil
+	}
+
+	msg := fmt.Sprintf("function line is %d characters", lineLen)
+	diagnostic, err := _diagnostic(file, startLine, endLine, startLine+longest, newLines, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []analysis.Diagnostic{diagnostic}, lintedLines, nil
+}
+
+// _getFuncIssuesForFile updates lintedLines in place.
+func _getFuncIssuesForFile(
+	file *_file,
+	maxLineLen, tabSpaces int,
+	lintedLines map[int]bool,
+) ([]analysis.Diagnostic, error) {
+	var diagnostics []analysis.Diagnostic
+	var err error
+
+	for _, node := range file.AstFile.Decls {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		funcIssues, funcLines, thisErr := _getFuncIssue(file, funcDecl, maxLineLen, tabSpaces)
+		if thisErr != nil {
+			// We'll ignore this function declaration, but mark its error.
+			err = thisErr
+
+			continue
+		}
+		for _, lintedLine := range funcLines {
+			lintedLines[lintedLine] = true
+		}
+
+		diagnostics = append(diagnostics, funcIssues...)
+	}
+
+	return diagnostics, err
+}
@@ -14,6 +14,9 @@ package linters
 // we do fix some common cases:
 //    1) Long lines in comment blocks
 //    2) Long lines in function declarations
+//    3) Long lines containing a function call or composite literal
+//    4) Long lines containing a string literal (if -linewrap.split-strings
+//       is set)
 
 import (
 	"fmt"
@@ -28,6 +31,8 @@ import (
 	"golang.org/x/tools/go/analysis"
 )
 
+// These are the defaults; they can be changed via flags (see
+// linewrap_config.go).
 const (
 	gmaxCommentLineLen = 79
 	gmaxCodeLineLen    = 100 // copied from `lll` linter
@@ -36,7 +41,8 @@ const (
 
 // We allow long lines that have any of these patterns.
 // Note that this only applies to non-comment lines, comment
-// lines have their own check in _isMachineReadableComment.
+// lines have their own check in _isMachineReadableComment.  More
+// patterns can be added via the -linewrap.exception flag.
 var gexceptionsRegexp = regexp.MustCompile(
 	// Lines with a single string at the end (possibly followed by
 	// punctuation), where the pre-string portion isn't too-long on
@@ -56,8 +62,8 @@ var gexceptionsRegexp = regexp.MustCompile(
 
 var LinewrapAnalyzer = &analysis.Analyzer{
 	Name: "linewrap",
-	Doc: "check that comment lines fit in 80 chars, and func decls in 100. " +
-		"Auto-fixing will rewrap both types of lines to fit.",
+	Doc: "check that comment lines fit in 80 chars, and func decls in 100 " +
+		"(configurable via flags). Auto-fixing will rewrap both types of lines to fit.",
 	Run: _runLinewrap,
 }
 
@@ -117,12 +123,21 @@ func (f *_file) NumLines() (int, error) {
 
 // LineEnd points to the newline at the end of the line (or the end
 // of the file if the file doesn't end in a newline).
-func (f *_file) LineEnd(lineNumber int) token.Pos {
-	if lineNumber+1 < len(f.lines)-1 {
+func (f *_file) LineEnd(lineNumber int) (token.Pos, error) {
+	if lineNumber < f.LineCount() {
 		// If there's a next line, replace up to the start of that.
-		return f.LineStart(lineNumber+1) - 1
+		return f.LineStart(lineNumber+1) - 1, nil
 	}
-	return f.AstFile.End()
+	err := f.cacheFile()
+	if err != nil {
+		return token.NoPos, fmt.Errorf("%w", err)
+	}
+	end := token.Pos(f.Base() + f.Size())
+	if strings.HasSuffix(f.contents, "\n") {
+		end--
+	}
+
+	return end, nil
 }
 
 func (f *_file) LineText(lineNumber int) (string, error) {
@@ -150,7 +165,7 @@ func _diagnostic(
 	startLine, endLine, errorLine int,
 	replacement []string,
 	message string,
-) analysis.Diagnostic {
+) (analysis.Diagnostic, error) {
 	errorPos := file.LineStart(errorLine)
 	retval := analysis.Diagnostic{
 		Pos:     errorPos,
@@ -158,19 +173,22 @@ func _diagnostic(
 	}
 	if len(replacement) > 0 {
 		startPos := file.LineStart(startLine)
-		endPos := file.LineEnd(endLine) + 1
+		endPos, err := file.LineEnd(endLine)
+		if err != nil {
+			return retval, fmt.Errorf("%w", err)
+		}
 		// We have to add trailing newlines to each replacement line.
 		newText := []byte(strings.Join(replacement, "\n") + "\n")
 		suggestedFix := analysis.SuggestedFix{
 			Message: "Reflowed text",
 			TextEdits: []analysis.TextEdit{
-				{Pos: startPos, End: endPos, NewText: newText},
+				{Pos: startPos, End: endPos + 1, NewText: newText},
 			},
 		}
 		retval.SuggestedFixes = []analysis.SuggestedFix{suggestedFix}
 	}
 
-	return retval
+	return retval, nil
 }
 
 // Allows a line that's a url all by itself, or `// [1] <url>`.
@@ -253,8 +271,18 @@ func _shareCommentBlock(line, otherLine string) bool {
 	return linePrefix == _commentPrefix(otherLine)
 }
 
-func _linewrapComments(lines []string, maxCommentLineLen, tabSpaces int) []string {
+// _linewrapComments reflows the given comment block.  All lines but the first
+// get the given hanging prefix, if set (e.g. for a list item), or else the
+// prefix of the first line.
+func _linewrapComments(
+	lines []string,
+	hanging string,
+	maxCommentLineLen, tabSpaces int,
+) []string {
 	prefix := _commentPrefix(lines[0])
+	if hanging == "" {
+		hanging = prefix
+	}
 
 	// If we're reformatting anyway, let's give a bit of space on the
 	// right margin so the comments don't look very crowded.
@@ -265,7 +293,7 @@ func _linewrapComments(lines []string, maxCommentLineLen, tabSpaces int) []strin
 	for _, line := range lines {
 		// Get rid of the comment prefix, but make sure every word
 		// ends with a space.
-		line = line[len(prefix):] + " "
+		line = line[len(_commentPrefix(line)):] + " "
 		// We don't split on tabs, just spaces.
 		for _, word := range strings.SplitAfter(line, " ") {
 			// In some cases we can end up with empty words; just ignore those.
@@ -277,7 +305,7 @@ func _linewrapComments(lines []string, maxCommentLineLen, tabSpaces int) []strin
 			// We have a `-1` here because if we're the last word on
 			// the line our trailing space will be deleted below.
 			if lineLen+_lineLength(word, tabSpaces)-1 > maxCommentLineLen {
-				retval = append(retval, prefix) // start a new line
+				retval = append(retval, hanging) // start a new line
 			}
 			retval[len(retval)-1] += word
 		}
@@ -295,14 +323,15 @@ func _getCommentBlockIssue(
 	file *_file,
 	startLine int,
 	lines []string,
+	hanging string,
 	maxCommentLineLen, tabSpaces int,
-) []analysis.Diagnostic {
+) ([]analysis.Diagnostic, error) {
 	// If this block consists only of a single, machine-readable
 	// comment, then skip it; we can't wrap machine-read code.  (And
 	// `_shareCommentBlock` ensures we'll never see a machine-readable
 	// comment-line in the same block as any other comment-line.)
 	if len(lines) == 1 && _isMachineReadableComment(lines[0]) {
-		return nil
+		return nil, nil
 	}
 
 	for i, line := range lines {
@@ -311,16 +340,19 @@ func _getCommentBlockIssue(
 		// Look for lines that are too-long (and aren't just a URL)
 		if lineLen > maxCommentLineLen && !_urlComment.MatchString(line) {
 			message := fmt.Sprintf("comment line is %d characters", lineLen)
-			replacement := _linewrapComments(lines, maxCommentLineLen, tabSpaces)
-			diagnostic := _diagnostic(
+			replacement := _linewrapComments(lines, hanging, maxCommentLineLen, tabSpaces)
+			diagnostic, err := _diagnostic(
 				file, startLine, startLine+len(lines)-1, startLine+i,
 				replacement, message)
+			if err != nil {
+				return nil, err
+			}
 
-			return []analysis.Diagnostic{diagnostic}
+			return []analysis.Diagnostic{diagnostic}, nil
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
 // _getCommentIssuesForFile updates lintedLines in place.
@@ -362,23 +394,21 @@ func _getCommentIssuesForFile(
 
 		// Now break up this comment-group into "blocks".  We consider
 		// a single group of comments to potentially be multiple
-		// blocks if it has, e.g., a list in it.
-		blockStarts := []int{0}
-		lastBlockStartLine := commentLines[0]
-
-		for i := 1; i < len(commentLines); i++ {
-			if !_shareCommentBlock(commentLines[i], lastBlockStartLine) {
-				blockStarts = append(blockStarts, i)
-				lastBlockStartLine = commentLines[i]
+		// blocks if it has, e.g., a list or code in it; see
+		// linewrap_doc.go.
+		//
+		// Finally, analyze and linewrap each block separately.  We
+		// never complain about blocks we can't reflow, like code.
+		for _, block := range _commentBlocks(commentLines) {
+			if !block.reflow {
+				continue
+			}
+			blockIssues, err := _getCommentBlockIssue(
+				file, startLine+block.start, block.lines, block.hanging,
+				maxCommentLineLen, tabSpaces)
+			if err != nil {
+				return nil, err
 			}
-		}
-		blockStarts = append(blockStarts, len(commentLines)) // sentinel
-
-		// Finally, analyze and linewrap each block separately.
-		for i := 0; i < len(blockStarts)-1; i++ {
-			block := commentLines[blockStarts[i]:blockStarts[i+1]]
-			blockIssues := _getCommentBlockIssue(
-				file, startLine+blockStarts[i], block, maxCommentLineLen, tabSpaces)
 			diagnostics = append(diagnostics, blockIssues...)
 		}
 	}
@@ -386,137 +416,6 @@ func _getCommentIssuesForFile(
 	return diagnostics, nil
 }
 
-// lintedLine is set to the line-number of this funcDecl line if we
-// successfully linted it (it's always one-line func decl in that
-// case), or -1 if not.
-func _getFuncIssue(
-	file *_file,
-	funcDecl *ast.FuncDecl,
-	maxLineLen, tabSpaces int,
-) (diagnostics []analysis.Diagnostic, lintedLine int, err error) {
-	startLine := file.Line(funcDecl.Pos())
-	// This is the end of the decl: the pos of the `{` that starts the body
-	endLine := file.Line(funcDecl.Body.Pos())
-
-	line, err := file.LineText(startLine)
-	if err != nil {
-		return nil, -1, fmt.Errorf("%w", err)
-	}
-
-	lineLen := _lineLength(line, tabSpaces)
-	if lineLen <= maxLineLen {
-		return nil, startLine, nil
-	}
-
-	if endLine > startLine {
-		// We don't try to auto-wrap multi-line function declarations.
-		// Return `-1` so this line will get linted again (normally) later.
-		return nil, -1, nil
-	}
-
-	params := funcDecl.Type.Params
-	if len(params.List) == 0 {
-		// If there are no params our auto-wrapping may not look
-		// very nice (and would require special handling where we index
-		// params.List below).  So for now, we don't try to suggest there.
-		return nil, -1, nil
-	}
-
-	// Now let's do the auto-reformatting.
-	//
-	// We only want to replace the function params, not the parens that
-	// surround them, so we start with params.List[0].Pos() instead of
-	// params.Pos().
-	firstLine, err := file.Range(funcDecl.Pos(), params.List[0].Pos())
-	if err != nil {
-		return nil, -1, fmt.Errorf("%w", err)
-	}
-	newLines := []string{firstLine}
-
-	for i, param := range params.List {
-		// "gofmt" doesn't allow comments before a parameter
-		// declaration, but it does allow them after.  We have to make
-		// sure we include the latter.
-		paramStart := param.Pos()
-		paramEnd := param.End()
-		if param.Comment != nil {
-			paramEnd = param.Comment.End()
-		}
-		// TODO(csilvers): param.Comment seems to always be empty!
-		// We can't fix this in general, but we can for the last
-		// param in the argument list.  It's grody though.
-		if i == len(params.List)-1 {
-			paramEnd = params.Closing
-		}
-
-		// We need to insert a trailing comma, but gofmt wants it
-		// before any trailing comments that follow the param.
-		lineText, paramErr := file.Range(paramStart, param.End())
-		if paramErr != nil {
-			return nil, -1, fmt.Errorf("%w", paramErr)
-		}
-		lineText += ","
-		if param.End() != paramEnd {
-			commentText, paramErr := file.Range(param.End(), paramEnd)
-			if paramErr != nil {
-				return nil, -1, fmt.Errorf("%w", paramErr)
-			}
-			lineText += commentText
-		}
-
-		newLines = append(newLines, "\t"+lineText)
-	}
-
-	// TODO(csilvers): also reformat the return types if they are too
-	// long.  (Useful for this is params.Closing and
-	// funcDecl.Body.Lbrace.)  But for now we take everything from the
-	// closing paren of the function declaration to the end of the
-	// line, and put it in as our last-line.  Maybe we should also have a
-	// TODO(csilvers): break up `func foo(...) { on same line }`.
-	lastLine, err := file.Range(params.Closing, file.LineEnd(endLine))
-	if err != nil {
-		return nil, -1, fmt.Errorf("%w", err)
-	}
-	newLines = append(newLines, lastLine)
-
-	msg := fmt.Sprintf("function line is %d characters", lineLen)
-	diagnostic := _diagnostic(file, startLine, startLine, startLine, newLines, msg)
-
-	return []analysis.Diagnostic{diagnostic}, startLine, nil
-}
-
-// _getFuncIssuesForFile updates lintedLines in place.
-func _getFuncIssuesForFile(
-	file *_file,
-	maxLineLen, tabSpaces int,
-	lintedLines map[int]bool,
-) ([]analysis.Diagnostic, error) {
-	var diagnostics []analysis.Diagnostic
-	var err error
-
-	for _, node := range file.AstFile.Decls {
-		funcDecl, ok := node.(*ast.FuncDecl)
-		if !ok {
-			continue
-		}
-
-		funcIssues, lintedLine, thisErr := _getFuncIssue(file, funcDecl, maxLineLen, tabSpaces)
-		if thisErr != nil {
-			// We'll ignore this function declaration, but mark its error.
-			err = thisErr
-
-			continue
-		}
-		if lintedLine > -1 {
-			lintedLines[lintedLine] = true
-		}
-
-		diagnostics = append(diagnostics, funcIssues...)
-	}
-
-	return diagnostics, err
-}
-
 // _findOKStructFields finds lines that are long just because of
 // struct tags, e.g.:
 //    struct MyStruct {
@@ -592,7 +491,7 @@ func _getLonglineIssuesForFile(
 			return nil, fmt.Errorf("%w", err)
 		}
 
-		if gexceptionsRegexp.MatchString(line) {
+		if _isLonglineException(line) {
 			continue
 		}
 
@@ -608,7 +507,11 @@ func _getLonglineIssuesForFile(
 		lineLen := _lineLength(line, tabSpaces)
 		if lineLen > maxLineLen {
 			msg := fmt.Sprintf("line is %d characters", lineLen)
-			diagnostics = append(diagnostics, _diagnostic(file, i, i, i, nil, msg))
+			diagnostic, err := _diagnostic(file, i, i, i, nil, msg)
+			if err != nil {
+				return nil, err
+			}
+			diagnostics = append(diagnostics, diagnostic)
 		}
 	}
 
@@ -616,22 +519,28 @@ func _getLonglineIssuesForFile(
 }
 
 func _runLinewrap(pass *analysis.Pass) (interface{}, error) {
+	err := _validateLinewrapLimits()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, f := range pass.Files {
 		file := _file{File: pass.Fset.File(f.Pos()), AstFile: f}
 		fmt.Println(file.Name())
+		limits := _linewrapLimitsFor(file.Name())
 
 		var diagnostics []analysis.Diagnostic
 		lintedLines := make(map[int]bool)
 
 		commentIssues, err := _getCommentIssuesForFile(
-			&file, gmaxCommentLineLen, gtabSpaces, lintedLines)
+			&file, limits.maxCommentLineLen, limits.tabSpaces, lintedLines)
 		if err != nil {
 			return nil, fmt.Errorf("%w", err)
 		}
 		diagnostics = append(diagnostics, commentIssues...)
 
 		funcIssues, err := _getFuncIssuesForFile(
-			&file, gmaxCodeLineLen, gtabSpaces, lintedLines)
+			&file, limits.maxCodeLineLen, limits.tabSpaces, lintedLines)
 		if err != nil {
 			return nil, fmt.Errorf("%w", err)
 		}
@@ -641,10 +550,26 @@ func _runLinewrap(pass *analysis.Pass) (interface{}, error) {
 		_findOKStructFields(&file, lintedLines)
 		_findOKRawStrings(&file, lintedLines)
 
+		callIssues, err := _getCallIssuesForFile(
+			&file, limits.maxCodeLineLen, limits.tabSpaces, lintedLines)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		diagnostics = append(diagnostics, callIssues...)
+
+		if _linewrapSplitStrings {
+			stringIssues, err := _getStringIssuesForFile(
+				&file, limits.maxCodeLineLen, limits.tabSpaces, lintedLines)
+			if err != nil {
+				return nil, fmt.Errorf("%w", err)
+			}
+			diagnostics = append(diagnostics, stringIssues...)
+		}
+
 		// Now do the normal `lll` (too-long-line) linting.  We ignore
 		// all line #s in lintedLines so they're not linted twice.
 		longlineIssues, err := _getLonglineIssuesForFile(
-			&file, gmaxCodeLineLen, gtabSpaces, lintedLines)
+			&file, limits.maxCodeLineLen, limits.tabSpaces, lintedLines)
 		if err != nil {
 			return nil, fmt.Errorf("%w", err)
 		}
@@ -0,0 +1,47 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestLinewrapConfig(t *testing.T) {
+	setFlag(t, linters.LinewrapAnalyzer, "comment-max", "40")
+	setFlag(t, linters.LinewrapAnalyzer, "code-max", "50")
+	setFlag(t, linters.LinewrapAnalyzer, "tab-width", "8")
+	setFlag(t, linters.LinewrapAnalyzer, "override", `/wide\.go$=70:60`)
+	setFlag(t, linters.LinewrapAnalyzer, "exception", `^var exempt = `)
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapcfg")
+}
+
+func TestLinewrapCalls(t *testing.T) {
+	setFlag(t, linters.LinewrapAnalyzer, "code-max", "40")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapcalls")
+}
+
+func TestLinewrapFuncs(t *testing.T) {
+	setFlag(t, linters.LinewrapAnalyzer, "code-max", "50")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapfuncs")
+	// linewrapfuncsfixed is the result of the fix: running it again should
+	// change nothing.
+	analysistest.Run(t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapfuncsfixed")
+}
+
+func TestLinewrapDocComments(t *testing.T) {
+	setFlag(t, linters.LinewrapAnalyzer, "comment-max", "40")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapdoc")
+}
+
+func TestLinewrapSplitStrings(t *testing.T) {
+	setFlag(t, linters.LinewrapAnalyzer, "code-max", "40")
+	setFlag(t, linters.LinewrapAnalyzer, "split-strings", "true")
+	analysistest.RunWithSuggestedFixes(
+		t, analysistest.TestData(), linters.LinewrapAnalyzer, "linewrapstrings")
+}
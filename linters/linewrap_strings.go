@@ -0,0 +1,189 @@
+package linters
+
+// This file contains the (opt-in, via -linewrap.split-strings) auto-fixing of
+// long lines containing a string literal, which we split into several
+// literals at word boundaries, as in
+//	x := "a long string " +
+//		"which continues here"
+// We only split interpreted ("...") strings, and never those in imports or
+// struct tags, or those we'd change the meaning of by splitting (e.g.
+// `"abc"[i]`).
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// _stringLiteralUnits splits the contents of an interpreted string literal
+// (without the quotes) into runes and escape sequences, which we must not
+// split.
+func _stringLiteralUnits(contents string) []string {
+	var units []string
+	for i := 0; i < len(contents); {
+		size := 1
+		if contents[i] == '\\' && i+1 < len(contents) {
+			switch c := contents[i+1]; {
+			case c == 'x':
+				size = len(`\x00`)
+			case c == 'u':
+				size = len(`\u0000`)
+			case c == 'U':
+				size = len(`\U00000000`)
+			case '0' <= c && c <= '7':
+				size = len(`\000`)
+			default:
+				size = len(`\n`)
+			}
+		} else {
+			_, size = utf8.DecodeRuneInString(contents[i:])
+		}
+		if i+size > len(contents) {
+			size = len(contents) - i
+		}
+		units = append(units, contents[i:i+size])
+		i += size
+	}
+
+	return units
+}
+
+// _splittableStrings returns the interpreted string literals in the file we
+// may split, by the line they're on (if they're entirely on one line).
+func _splittableStrings(file *_file) map[int][]*ast.BasicLit {
+	excluded := map[*ast.BasicLit]bool{}
+	retval := map[int][]*ast.BasicLit{}
+	ast.Inspect(file.AstFile, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			excluded[node.Tag] = true
+		case *ast.IndexExpr:
+			if lit, ok := node.X.(*ast.BasicLit); ok {
+				excluded[lit] = true
+			}
+		case *ast.SliceExpr:
+			if lit, ok := node.X.(*ast.BasicLit); ok {
+				excluded[lit] = true
+			}
+		case *ast.BasicLit:
+			if node.Kind == token.STRING && strings.HasPrefix(node.Value, `"`) &&
+				!excluded[node] && file.Line(node.Pos()) == file.Line(node.End()) {
+				line := file.Line(node.Pos())
+				retval[line] = append(retval[line], node)
+			}
+		}
+
+		return true
+	})
+
+	return retval
+}
+
+// _splitString returns the lines with which to replace the given line to
+// split the given string literal on it, or nil if we can't split it such
+// that each part (but perhaps the last line) fits.
+func _splitString(
+	file *_file,
+	lineNum int,
+	line string,
+	lit *ast.BasicLit,
+	maxLineLen, tabSpaces int,
+) []string {
+	lineStart := file.LineStart(lineNum)
+	litStart := int(lit.Pos() - lineStart)
+	litEnd := int(lit.End() - lineStart)
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+	// We split greedily, after a space (or newline), so as to fill each line.
+	var newLines []string
+	prefix := line[:litStart] + `"`
+	current := ""
+	lastBreak := -1 // the index in current after which we may split
+	for _, unit := range _stringLiteralUnits(lit.Value[1 : len(lit.Value)-1]) {
+		current += unit
+		if unit == " " || unit == `\n` {
+			lastBreak = len(current)
+		}
+		if _lineLength(prefix+current+`" +`, tabSpaces) <= maxLineLen {
+			continue
+		}
+		if lastBreak <= 0 {
+			return nil // a single word is too long
+		}
+
+		newLines = append(newLines, prefix+current[:lastBreak]+`" +`)
+		prefix = indent + "\t" + `"`
+		current = current[lastBreak:]
+		lastBreak = -1
+	}
+	if len(newLines) == 0 || current == "" {
+		return nil
+	}
+
+	return append(newLines, prefix+current+`"`+line[litEnd:])
+}
+
+// _getStringIssuesForFile reports long lines containing a string literal,
+// with a fix to split it.  It updates lintedLines in place.
+func _getStringIssuesForFile(
+	file *_file,
+	maxLineLen, tabSpaces int,
+	lintedLines map[int]bool,
+) ([]analysis.Diagnostic, error) {
+	var diagnostics []analysis.Diagnostic
+
+	numLines, err := file.NumLines()
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	litsByLine := _splittableStrings(file)
+	for lineNum := 1; lineNum <= numLines; lineNum++ {
+		lits := litsByLine[lineNum]
+		if len(lits) == 0 || lintedLines[lineNum] {
+			continue
+		}
+
+		line, err := file.LineText(lineNum)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		// We never touch directives like //go:embed, or lines we were
+		// explicitly told to allow.
+		if strings.Contains(line, "//go:") || _linewrapExceptions.matches(line) {
+			continue
+		}
+		lineLen := _lineLength(line, tabSpaces)
+		if lineLen <= maxLineLen {
+			continue
+		}
+
+		// Split the longest string on the line.
+		longest := lits[0]
+		for _, lit := range lits[1:] {
+			if len(lit.Value) > len(longest.Value) {
+				longest = lit
+			}
+		}
+
+		newLines := _splitString(file, lineNum, line, longest, maxLineLen, tabSpaces)
+		if newLines == nil {
+			continue
+		}
+		msg := fmt.Sprintf("line is %d characters", lineLen)
+		diagnostic, err := _diagnostic(file, lineNum, lineNum, lineNum, newLines, msg)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, diagnostic)
+		lintedLines[lineNum] = true
+	}
+
+	return diagnostics, nil
+}
@@ -0,0 +1,480 @@
+package linters
+
+// This file contains the suggested fix for LogAnalyzer, which rewrites
+//	logger.Info(fmt.Sprintf("user %s did %d", u, req.Count))
+// to
+//	logger.Info("user did", log.Fields{"u": u, "req_count": req.Count})
+// using whatever map-type the logging method accepts as its (variadic)
+// fields, or the equivalent for the logger's _logFieldStyle, e.g.
+//	logger.Info("user did", "u", u, "req_count", req.Count)        // slog
+//	logger.Info("user did", zap.Any("u", u), ...)                 // zap
+//	logger.Info().Interface("u", u)...Msg("user did")             // zerolog
+//	logger.WithFields(logrus.Fields{"u": u, ...}).Info("user did") // logrus
+//
+// If the Sprintf was the file's last use of fmt, the fix removes the import.
+//
+// We only fix simple cases: the format must be a constant with only simple
+// verbs (no `%[1]d` or `%*d`), and each argument must be something we can
+// name (a variable, a field, or a method-call with no arguments).  Otherwise
+// we just report the problem.
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// _sprintfMessage returns the format with its verbs removed, for use as a
+// fixed log message, or ok=false if the format is too complex or doesn't
+// match the number of arguments.
+func _sprintfMessage(format string, numArgs int) (message string, ok bool) {
+	var builder strings.Builder
+	numVerbs := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			builder.WriteByte(format[i])
+
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			builder.WriteByte('%')
+
+			continue
+		}
+		// Skip flags, width, and precision, then the verb.
+		for i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) != -1 {
+			i++
+		}
+		if i >= len(format) || !('a' <= format[i] && format[i] <= 'z' ||
+			'A' <= format[i] && format[i] <= 'Z') {
+			return "", false // e.g. %[1]d or %*d
+		}
+		numVerbs++
+	}
+	if numVerbs != numArgs {
+		return "", false
+	}
+
+	message = _cleanLogMessage(builder.String())
+
+	return message, message != ""
+}
+
+var (
+	// Delimiters that were around a verb, e.g. the quotes in `"%s"`.
+	_emptyDelimiters = regexp.MustCompile(`""|''|\(\)|\[\]|\{\}|<>`)
+	// Spaces before punctuation, e.g. `failed to load %s: %v`.
+	_spaceBeforePunctuation = regexp.MustCompile(`\s+([:;,.])`)
+	_multipleSpaces         = regexp.MustCompile(`\s+`)
+)
+
+// _cleanLogMessage tidies up a format with its verbs removed.
+func _cleanLogMessage(message string) string {
+	message = _emptyDelimiters.ReplaceAllString(message, "")
+	message = _spaceBeforePunctuation.ReplaceAllString(message, "$1")
+	message = _multipleSpaces.ReplaceAllString(message, " ")
+
+	return strings.Trim(message, " :;,=")
+}
+
+// _logFieldKey returns the key to use for the given argument, derived from
+// the expression: `u` -> "u", `req.UserID` -> "req_user_id", and
+// `u.Name()` -> "u_name", or ok=false if we can't derive one.
+func _logFieldKey(expr ast.Expr) (key string, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		words := _parseMixedCaps(expr.Name)
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+
+		return strings.Join(words, "_"), true
+	case *ast.SelectorExpr:
+		prefix, ok := _logFieldKey(expr.X)
+		if !ok {
+			return "", false
+		}
+		key, _ := _logFieldKey(expr.Sel)
+
+		return prefix + "_" + key, true
+	case *ast.CallExpr:
+		if len(expr.Args) > 0 {
+			return "", false
+		}
+
+		return _logFieldKey(expr.Fun)
+	case *ast.ParenExpr:
+		return _logFieldKey(expr.X)
+	}
+
+	return "", false
+}
+
+// _logFieldsType returns the type of the fields accepted by the given
+// logging method: the element-type of its variadic parameter, if that's a
+// map with string keys.
+func _logFieldsType(funcObj types.Object) types.Type {
+	if funcObj == nil {
+		return nil
+	}
+	sig, ok := funcObj.Type().(*types.Signature)
+	if !ok || !sig.Variadic() {
+		return nil
+	}
+	slice, ok := sig.Params().At(sig.Params().Len() - 1).Type().(*types.Slice)
+	if !ok {
+		return nil
+	}
+	fieldsMap, ok := slice.Elem().Underlying().(*types.Map)
+	if !ok {
+		return nil
+	}
+	if key, ok := fieldsMap.Key().Underlying().(*types.Basic); !ok || key.Kind() != types.String {
+		return nil
+	}
+
+	return slice.Elem()
+}
+
+// _identsIn returns the objects referred to by identifiers in the given
+// expressions.
+func _identsIn(exprs []ast.Expr, typesInfo *types.Info) map[types.Object]bool {
+	retval := map[types.Object]bool{}
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && typesInfo.Uses[ident] != nil {
+				retval[typesInfo.Uses[ident]] = true
+			}
+
+			return true
+		})
+	}
+
+	return retval
+}
+
+// _assignedBetween returns true if any of the given objects is assigned to in
+// the file between the given positions.
+func _assignedBetween(
+	file *ast.File,
+	objs map[types.Object]bool,
+	start, end token.Pos,
+	typesInfo *types.Info,
+) bool {
+	assigned := false
+	isAssigned := func(expr ast.Expr) {
+		if ident, ok := expr.(*ast.Ident); ok && objs[typesInfo.ObjectOf(ident)] {
+			assigned = true
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil || node.End() < start || node.Pos() > end {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			if node.Pos() > start {
+				for _, lhs := range node.Lhs {
+					isAssigned(lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			isAssigned(node.X)
+		case *ast.UnaryExpr:
+			if node.Op == token.AND { // we can't track pointers
+				isAssigned(node.X)
+			}
+		}
+
+		return !assigned
+	})
+
+	return assigned
+}
+
+// _sprintfAssignment returns the statement `msg := fmt.Sprintf(...)`
+// assigning the given Sprintf, if it's the only thing on its lines, so that
+// we can remove it.
+func _sprintfAssignment(f *_file, sprintf *ast.CallExpr) *ast.AssignStmt {
+	var retval *ast.AssignStmt
+	ast.Inspect(f.AstFile, func(node ast.Node) bool {
+		assign, ok := node.(*ast.AssignStmt)
+		if ok && len(assign.Rhs) == 1 && assign.Rhs[0] == sprintf {
+			retval = assign
+		}
+
+		return retval == nil
+	})
+	if retval == nil {
+		return nil
+	}
+
+	lineEnd, err := f.LineEnd(f.Line(retval.End()))
+	if err != nil {
+		return nil
+	}
+	text, err := f.Range(f.LineStart(f.Line(retval.Pos())), lineEnd)
+	if err != nil {
+		return nil
+	}
+	stmtText, err := f.Range(retval.Pos(), retval.End())
+	if err != nil || strings.TrimSpace(text) != stmtText {
+		return nil
+	}
+
+	return retval
+}
+
+// _unusedSprintfImportEdits returns an edit removing the import of the
+// package of the given Sprintf (that is, fmt), if the Sprintf is its only use
+// in the file, or nothing otherwise.
+func _unusedSprintfImportEdits(
+	f *_file,
+	sprintf *ast.CallExpr,
+	typesInfo *types.Info,
+) []analysis.TextEdit {
+	sel, ok := sprintf.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	pkgIdent, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	pkgName, ok := typesInfo.Uses[pkgIdent].(*types.PkgName)
+	if !ok {
+		return nil
+	}
+	// Each file has its own PkgName, so this only finds uses in this file.
+	for ident, obj := range typesInfo.Uses {
+		if obj == pkgName && ident != pkgIdent {
+			return nil
+		}
+	}
+
+	for _, spec := range f.AstFile.Imports {
+		if typesInfo.Implicits[spec] != pkgName &&
+			(spec.Name == nil || typesInfo.Defs[spec.Name] != pkgName) {
+			continue
+		}
+		edit, ok := _deleteImportEdit(f, spec)
+		if !ok {
+			return nil
+		}
+
+		return []analysis.TextEdit{edit}
+	}
+
+	return nil
+}
+
+// _logFieldEdits returns the edits to add the given fields (keys and the
+// text of their values) to the given logging call, and the path of the
+// package we need to import for them (or "" if none).  It returns ok=false
+// if we can't.
+func _logFieldEdits(
+	pass *analysis.Pass,
+	file *ast.File,
+	call *ast.CallExpr,
+	logger *_loggerSpec,
+	keys, values []string,
+) (edits []analysis.TextEdit, importPath string, ok bool) {
+	funcObj := lintutil.ObjectFor(call.Fun, pass.TypesInfo)
+	lastArg := call.Args[len(call.Args)-1].End()
+	var fields []string
+
+	switch logger.fields {
+	case _logFieldsMap:
+		fieldsType := _logFieldsType(funcObj)
+		if fieldsType == nil {
+			return nil, "", false
+		}
+		typeExpr, importPath, ok := _typeExprIn(fieldsType, file, pass.Pkg, pass.TypesInfo)
+		if !ok {
+			if _, named := fieldsType.(*types.Named); named {
+				return nil, "", false
+			}
+			typeExpr = types.TypeString(fieldsType, types.RelativeTo(pass.Pkg))
+		}
+		for i, key := range keys {
+			fields = append(fields, strconv.Quote(key)+": "+values[i])
+		}
+		newText := ", " + typeExpr + "{" + strings.Join(fields, ", ") + "}"
+
+		return []analysis.TextEdit{{Pos: lastArg, End: lastArg, NewText: []byte(newText)}},
+			importPath, true
+
+	case _logFieldsKeyValue:
+		for i, key := range keys {
+			fields = append(fields, strconv.Quote(key)+", "+values[i])
+		}
+		newText := ", " + strings.Join(fields, ", ")
+
+		return []analysis.TextEdit{{Pos: lastArg, End: lastArg, NewText: []byte(newText)}},
+			"", true
+
+	case _logFieldsZap:
+		pkgName, importPath, ok := _packageNameIn(funcObj.Pkg(), file, pass.TypesInfo)
+		if !ok {
+			return nil, "", false
+		}
+		for i, key := range keys {
+			fields = append(fields, pkgName+".Any("+strconv.Quote(key)+", "+values[i]+")")
+		}
+		newText := ", " + strings.Join(fields, ", ")
+
+		return []analysis.TextEdit{{Pos: lastArg, End: lastArg, NewText: []byte(newText)}},
+			importPath, true
+
+	case _logFieldsZerolog, _logFieldsLogrus:
+		// Both add the fields by calling a method on the receiver (or, for
+		// logrus's package-level functions, a function in the package).
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return nil, "", false
+		}
+		var newText string
+		if logger.fields == _logFieldsZerolog {
+			for i, key := range keys {
+				newText += ".Interface(" + strconv.Quote(key) + ", " + values[i] + ")"
+			}
+		} else {
+			pkgName, path, ok := _packageNameIn(funcObj.Pkg(), file, pass.TypesInfo)
+			if !ok {
+				return nil, "", false
+			}
+			for i, key := range keys {
+				fields = append(fields, strconv.Quote(key)+": "+values[i])
+			}
+			newText = ".WithFields(" + pkgName + ".Fields{" + strings.Join(fields, ", ") + "})"
+			importPath = path
+		}
+
+		return []analysis.TextEdit{{Pos: sel.X.End(), End: sel.X.End(), NewText: []byte(newText)}},
+			importPath, true
+	}
+
+	return nil, "", false
+}
+
+// _structuredLogFixes returns a fix that rewrites the given logging call,
+// which logs the given Sprintf (inline or via a variable), to log a fixed
+// message with fields.  If we can't, we return no fixes.
+func _structuredLogFixes(
+	pass *analysis.Pass,
+	file *ast.File,
+	call, sprintf *ast.CallExpr,
+	logger *_loggerSpec,
+) []analysis.SuggestedFix {
+	sprintfName := lintutil.NameOf(lintutil.ObjectFor(sprintf.Fun, pass.TypesInfo))
+	if call.Ellipsis.IsValid() || sprintf.Ellipsis.IsValid() ||
+		sprintfName != "fmt.Sprintf" || len(sprintf.Args) == 0 {
+		return nil
+	}
+	f := &_file{File: pass.Fset.File(file.Pos()), AstFile: file}
+	messageArg := call.Args[logger.message]
+
+	format := pass.TypesInfo.Types[sprintf.Args[0]].Value
+	if format == nil || format.Kind() != constant.String {
+		return nil
+	}
+	args := sprintf.Args[1:]
+	message, ok := _sprintfMessage(constant.StringVal(format), len(args))
+	if !ok {
+		return nil
+	}
+
+	// Build the fields.
+	var keys, values []string
+	seen := map[string]bool{}
+	for _, arg := range args {
+		key, ok := _logFieldKey(arg)
+		if !ok || seen[key] {
+			return nil
+		}
+		seen[key] = true
+		value, err := f.Range(arg.Pos(), arg.End())
+		if err != nil {
+			return nil
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	fieldEdits, importPath, ok := _logFieldEdits(pass, file, call, logger, keys, values)
+	if !ok {
+		return nil
+	}
+
+	edits := append([]analysis.TextEdit{{
+		Pos:     messageArg.Pos(),
+		End:     messageArg.End(),
+		NewText: []byte(strconv.Quote(message)),
+	}}, fieldEdits...)
+	if importPath != "" {
+		edits = append(edits, _addImportEdit(file, importPath))
+	}
+	// If the Sprintf was stored in a variable, we're now using its arguments
+	// here instead, so they must not have changed in between; and we remove
+	// the variable if this was its only use.
+	removesSprintf := true
+	if sprintf.Pos() < call.Pos() || sprintf.Pos() > call.End() {
+		removesSprintf = false
+		if _assignedBetween(file, _identsIn(args, pass.TypesInfo), sprintf.End(), call.Pos(),
+			pass.TypesInfo) {
+			return nil
+		}
+		scope := pass.Pkg.Scope().Innermost(call.Pos())
+		for arg := range _identsIn(args, pass.TypesInfo) {
+			if arg.Parent() == nil {
+				continue // a field or method
+			}
+			if scope == nil {
+				return nil
+			}
+			if _, obj := scope.LookupParent(arg.Name(), call.Pos()); obj != arg {
+				return nil // it's not in scope (or is shadowed) here
+			}
+		}
+
+		msgObj := pass.TypesInfo.Uses[messageArg.(*ast.Ident)]
+		uses := 0
+		for _, obj := range pass.TypesInfo.Uses {
+			if obj == msgObj {
+				uses++
+			}
+		}
+		if uses == 1 {
+			assign := _sprintfAssignment(f, sprintf)
+			if assign == nil || assign.Tok != token.DEFINE {
+				return nil
+			}
+			lineEnd, err := f.LineEnd(f.Line(assign.End()))
+			if err != nil {
+				return nil
+			}
+			edits = append(edits, analysis.TextEdit{
+				Pos: f.LineStart(f.Line(assign.Pos())),
+				End: lineEnd + 1,
+			})
+			removesSprintf = true
+		}
+	}
+	// If that was the file's last use of fmt, we remove the import too.
+	if removesSprintf {
+		edits = append(edits, _unusedSprintfImportEdits(f, sprintf, pass.TypesInfo)...)
+	}
+
+	return []analysis.SuggestedFix{{
+		Message:   "Use a fixed message with fields",
+		TextEdits: edits,
+	}}
+}
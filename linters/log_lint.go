@@ -1,5 +1,7 @@
 // Package linters contains logging-related linters, in particular checking we
-// don't log an Sprintf'ed value (but use fields instead).
+// don't log an Sprintf'ed value (but use fields instead).  Which calls are
+// logging calls, for Khan's logger and other logging libraries, is configured
+// in log_loggers.go.
 package linters
 
 import (
@@ -84,60 +86,43 @@ func _sprintfs(file *ast.File, typesInfo *types.Info) map[types.Object]*ast.Call
 	return retval
 }
 
-func receiverIsLogger(funcObj types.Object) bool {
-	if funcObj == nil {
-		return false
-	}
-	funcType, ok := funcObj.Type().(*types.Signature)
-	if !ok {
-		return false
-	}
-	recv := funcType.Recv()
-	if recv == nil {
-		return false
-	}
-	recvType := recv.Type()
-	// TODO(benkraft): If we ever have methods of Logger for which printfs
-	// *are* valid arguments, check funcObj.Name() as well.
-	return recvType != nil &&
-		recvType.String() == "github.com/Khan/webapp/pkg/lib/log.Logger"
-}
-
-// _isBadLoggingCall checks if the node is a call to log using Sprintf, and if
-// so, returns the Sprintf expression.
+// _isBadLoggingCall checks if the node is a call to log using Sprintf (as its
+// message), and if so, returns the Sprintf expression and the logger's spec.
 //
 // sprintfs should be the return value of _sprintfs().
 func _isBadLoggingCall(
 	node ast.Node,
 	sprintfs map[types.Object]*ast.CallExpr,
 	typesInfo *types.Info,
-) *ast.CallExpr {
+) (*ast.CallExpr, *_loggerSpec) {
 	call, ok := node.(*ast.CallExpr)
 	if !ok {
-		return nil
+		return nil, nil
 	}
 
-	funcObj := lintutil.ObjectFor(call.Fun, typesInfo)
-	if !receiverIsLogger(funcObj) {
-		return nil
+	logger := _loggerFor(lintutil.ObjectFor(call.Fun, typesInfo), _logCustomLoggers)
+	if logger == nil {
+		return nil, nil
 	}
 
-	// We're interested in the first arg, the message
-	if len(call.Args) < 1 { // the arg is required, but just to be safe
-		return nil
+	// We're interested in the message arg
+	if len(call.Args) <= logger.message { // it's required, but just to be safe
+		return nil, nil
 	}
-	switch messageArg := call.Args[0].(type) {
+	switch messageArg := call.Args[logger.message].(type) {
 	case *ast.CallExpr:
 		// If the node is a Sprintf call, complain.
 		if _isSprintf(messageArg.Fun, typesInfo) {
-			return messageArg
+			return messageArg, logger
 		}
 	case *ast.Ident:
 		// If the node references a Sprintf call, also complain.
-		return sprintfs[typesInfo.Uses[messageArg]]
+		if sprintf := sprintfs[typesInfo.Uses[messageArg]]; sprintf != nil {
+			return sprintf, logger
+		}
 	}
 
-	return nil
+	return nil, nil
 }
 
 func _runLog(pass *analysis.Pass) (interface{}, error) {
@@ -149,7 +134,7 @@ func _runLog(pass *analysis.Pass) (interface{}, error) {
 		// form.  (Of course a perfect check is impossible.)
 		sprintfs := _sprintfs(file, pass.TypesInfo)
 		ast.Inspect(file, func(node ast.Node) bool {
-			badSprintf := _isBadLoggingCall(node, sprintfs, pass.TypesInfo)
+			badSprintf, logger := _isBadLoggingCall(node, sprintfs, pass.TypesInfo)
 			if badSprintf != nil {
 				var loc string
 				if node.Pos() > badSprintf.Pos() || badSprintf.Pos() > node.End() {
@@ -158,10 +143,13 @@ func _runLog(pass *analysis.Pass) (interface{}, error) {
 					loc = fmt.Sprintf(" (at %s)",
 						pass.Fset.Position(badSprintf.Pos()).String())
 				}
-				pass.Reportf(node.Pos(),
-					"avoid using Sprintf%s to log; "+
-						"instead use a fixed message with fields",
-					loc)
+				pass.Report(analysis.Diagnostic{
+					Pos: node.Pos(),
+					Message: fmt.Sprintf("avoid using Sprintf%s to log; "+
+						"instead use a fixed message with fields", loc),
+					SuggestedFixes: _structuredLogFixes(
+						pass, file, node.(*ast.CallExpr), badSprintf, logger),
+				})
 			}
 
 			return true // always recurse
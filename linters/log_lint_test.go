@@ -0,0 +1,27 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestLogFix(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), linters.LogAnalyzer, "logfix")
+}
+
+func TestLogLibraries(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), linters.LogAnalyzer, "loglibs")
+}
+
+func TestLogFixRemovesImport(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), linters.LogAnalyzer, "logfiximport")
+}
+
+func TestLogCustomLogger(t *testing.T) {
+	setFlag(t, linters.LogAnalyzer, "logger",
+		"type=logcustom.Printer,methods=Say,message=1,fields=key-value")
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), linters.LogAnalyzer, "logcustom")
+}
@@ -0,0 +1,275 @@
+package linters
+
+// This file contains the configuration of which functions LogAnalyzer and
+// LogOrReturnErrorAnalyzer treat as logging calls.  We know about Khan's
+// log.Logger, log/slog, zap, zerolog, and logrus; others may be added via the
+// (repeatable) flag
+//   -log.logger type=PKG.TYPE,methods=M1|M2,message=N,fields=STYLE
+//   -log.logger package=PKG,methods=M1|M2,message=N,fields=STYLE
+// (or -log_or_return_error.logger, which is the same) where:
+//   - type (or package) is the (pointer-)type whose methods, or package
+//     whose functions, log; exactly one must be given.
+//   - methods is the |-separated list of those methods (or functions) which
+//     log; it defaults to all methods of the type, and is required for a
+//     package.
+//   - message is the index of the argument which is the message (default 0).
+//   - fields is how the method accepts fields, which determines how we fix
+//     Sprintf'ed messages (default none); see _logFieldStyle.
+// Loggers given by flags take precedence over the built-in ones.
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/StevenACoffman/fixer/lintutil"
+)
+
+// _logFieldStyle is how a logging method accepts fields.
+type _logFieldStyle string
+
+const (
+	// As a trailing map, e.g. `log.Fields{"k": v}`.
+	_logFieldsMap _logFieldStyle = "map"
+	// As trailing alternating keys and values, e.g. `"k", v` (as for slog).
+	_logFieldsKeyValue _logFieldStyle = "key-value"
+	// As trailing zap.Fields, e.g. `zap.Any("k", v)`.
+	_logFieldsZap _logFieldStyle = "zap"
+	// Via method-calls on the zerolog.Event, e.g. `.Interface("k", v)`.
+	_logFieldsZerolog _logFieldStyle = "zerolog"
+	// Via logrus's WithFields, e.g. `.WithFields(logrus.Fields{"k": v})`.
+	_logFieldsLogrus _logFieldStyle = "logrus"
+	// Not at all (or not in a way we know how to fix).
+	_logFieldsNone _logFieldStyle = "none"
+)
+
+var _logFieldStyles = []_logFieldStyle{
+	_logFieldsMap, _logFieldsKeyValue, _logFieldsZap, _logFieldsZerolog,
+	_logFieldsLogrus, _logFieldsNone,
+}
+
+// _loggerSpec describes a set of logging methods or functions.
+type _loggerSpec struct {
+	pkgPath  string
+	typeName string          // or "" for package-level functions
+	methods  map[string]bool // or nil for all methods of the type
+	message  int             // the index of the message argument
+	fields   _logFieldStyle
+}
+
+func (s _loggerSpec) String() string {
+	parts := []string{"package=" + s.pkgPath}
+	if s.typeName != "" {
+		parts[0] = "type=" + s.pkgPath + "." + s.typeName
+	}
+	if s.methods != nil {
+		var methods []string
+		for method := range s.methods {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		parts = append(parts, "methods="+strings.Join(methods, "|"))
+	}
+	parts = append(parts,
+		fmt.Sprintf("message=%d", s.message), "fields="+string(s.fields))
+
+	return strings.Join(parts, ",")
+}
+
+// matches returns true if the given function is one of the spec's logging
+// methods.
+func (s _loggerSpec) matches(funcObj *types.Func) bool {
+	if funcObj.Pkg() == nil || (s.methods != nil && !s.methods[funcObj.Name()]) {
+		return false
+	}
+
+	recv := funcObj.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return s.typeName == "" && funcObj.Pkg().Path() == s.pkgPath
+	}
+	recvType := recv.Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+
+	return s.typeName != "" && lintutil.TypeIs(recvType, s.pkgPath, s.typeName)
+}
+
+// _loggerSpecs implements flag.Value for the (repeated) -log.logger flag.
+type _loggerSpecs []_loggerSpec
+
+func (l *_loggerSpecs) String() string {
+	parts := make([]string, len(*l))
+	for i, spec := range *l {
+		parts[i] = spec.String()
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (l *_loggerSpecs) Set(value string) error {
+	spec := _loggerSpec{fields: _logFieldsNone}
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return fmt.Errorf("invalid logger %q: %q must be KEY=VALUE", value, part)
+		}
+		switch key, val := kv[0], kv[1]; key {
+		case "type":
+			val = strings.TrimPrefix(val, "*")
+			sep := strings.LastIndex(val, ".")
+			if sep <= strings.LastIndex(val, "/") {
+				return fmt.Errorf("invalid logger %q: type must be PKG.TYPE", value)
+			}
+			spec.pkgPath, spec.typeName = val[:sep], val[sep+1:]
+		case "package":
+			spec.pkgPath = val
+		case "methods":
+			spec.methods = _methodSet(strings.Split(val, "|")...)
+		case "message":
+			var err error
+			spec.message, err = strconv.Atoi(val)
+			if err != nil || spec.message < 0 {
+				return fmt.Errorf("invalid logger %q: message must be an index", value)
+			}
+		case "fields":
+			spec.fields = _logFieldStyle(val)
+			if !_isLogFieldStyle(spec.fields) {
+				return fmt.Errorf("invalid logger %q: fields must be one of %v",
+					value, _logFieldStyles)
+			}
+		default:
+			return fmt.Errorf("invalid logger %q: unknown key %q", value, key)
+		}
+	}
+	if spec.pkgPath == "" {
+		return fmt.Errorf("invalid logger %q: type or package is required", value)
+	}
+	if spec.typeName == "" && spec.methods == nil {
+		return fmt.Errorf("invalid logger %q: methods is required for a package", value)
+	}
+
+	*l = append(*l, spec)
+
+	return nil
+}
+
+func _isLogFieldStyle(style _logFieldStyle) bool {
+	for _, known := range _logFieldStyles {
+		if style == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+func _methodSet(methods ...string) map[string]bool {
+	retval := map[string]bool{}
+	for _, method := range methods {
+		retval[method] = true
+	}
+
+	return retval
+}
+
+var (
+	_slogMethods        = _methodSet("Debug", "Info", "Warn", "Error")
+	_slogContextMethods = _methodSet("DebugContext", "InfoContext", "WarnContext", "ErrorContext")
+	_logrusMethods      = _methodSet(
+		"Trace", "Debug", "Info", "Print", "Warn", "Warning", "Error", "Fatal", "Panic")
+
+	_builtinLoggers = _loggerSpecs{
+		{
+			pkgPath:  "github.com/Khan/webapp/pkg/lib/log",
+			typeName: "Logger",
+			fields:   _logFieldsMap,
+		},
+
+		{pkgPath: "log/slog", methods: _slogMethods, fields: _logFieldsKeyValue},
+		{pkgPath: "log/slog", methods: _slogContextMethods, message: 1, fields: _logFieldsKeyValue},
+		{pkgPath: "log/slog", methods: _methodSet("Log"), message: 2, fields: _logFieldsKeyValue},
+		{pkgPath: "log/slog", methods: _methodSet("LogAttrs"), message: 2, fields: _logFieldsNone},
+		{
+			pkgPath: "log/slog", typeName: "Logger",
+			methods: _slogMethods, fields: _logFieldsKeyValue,
+		},
+		{
+			pkgPath: "log/slog", typeName: "Logger",
+			methods: _slogContextMethods, message: 1, fields: _logFieldsKeyValue,
+		},
+		{
+			pkgPath: "log/slog", typeName: "Logger",
+			methods: _methodSet("Log"), message: 2, fields: _logFieldsKeyValue,
+		},
+		{
+			pkgPath: "log/slog", typeName: "Logger",
+			methods: _methodSet("LogAttrs"), message: 2, fields: _logFieldsNone,
+		},
+
+		{
+			pkgPath: "go.uber.org/zap", typeName: "Logger",
+			methods: _methodSet("Debug", "Info", "Warn", "Error", "DPanic", "Panic", "Fatal"),
+			fields:  _logFieldsZap,
+		},
+		{
+			pkgPath: "go.uber.org/zap", typeName: "SugaredLogger",
+			methods: _methodSet(
+				"Debugw", "Infow", "Warnw", "Errorw", "DPanicw", "Panicw", "Fatalw"),
+			fields: _logFieldsKeyValue,
+		},
+		{
+			pkgPath: "go.uber.org/zap", typeName: "SugaredLogger",
+			methods: _methodSet("Debug", "Info", "Warn", "Error", "DPanic", "Panic", "Fatal"),
+			fields:  _logFieldsNone,
+		},
+
+		{
+			pkgPath: "github.com/rs/zerolog", typeName: "Event",
+			methods: _methodSet("Msg"), fields: _logFieldsZerolog,
+		},
+
+		{pkgPath: "github.com/sirupsen/logrus", methods: _logrusMethods, fields: _logFieldsLogrus},
+		{
+			pkgPath: "github.com/sirupsen/logrus", typeName: "Logger",
+			methods: _logrusMethods, fields: _logFieldsLogrus,
+		},
+		{
+			pkgPath: "github.com/sirupsen/logrus", typeName: "Entry",
+			methods: _logrusMethods, fields: _logFieldsLogrus,
+		},
+	}
+
+	// The -logger flags of LogAnalyzer and LogOrReturnErrorAnalyzer, which
+	// are set separately (as -log.logger and -log_or_return_error.logger).
+	_logCustomLoggers              _loggerSpecs
+	_logOrReturnErrorCustomLoggers _loggerSpecs
+)
+
+func init() {
+	usage := "type=PKG.TYPE or package=PKG, then ,methods=M1|M2,message=N,fields=STYLE: " +
+		"treat these methods as logging calls (may be repeated)"
+	LogAnalyzer.Flags.Var(&_logCustomLoggers, "logger", usage)
+	LogOrReturnErrorAnalyzer.Flags.Var(&_logOrReturnErrorCustomLoggers, "logger", usage)
+}
+
+// _loggerFor returns the spec for the logging method or function funcObj,
+// among the given custom loggers (from the analyzer's -logger flag) and the
+// builtin ones, or nil if it's not one.
+func _loggerFor(funcObj types.Object, customLoggers _loggerSpecs) *_loggerSpec {
+	fn, ok := funcObj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	for _, specs := range []_loggerSpecs{customLoggers, _builtinLoggers} {
+		for i := range specs {
+			if specs[i].matches(fn) {
+				return &specs[i]
+			}
+		}
+	}
+
+	return nil
+}
@@ -119,8 +119,11 @@ func _checkBlockForLogsAndReturns(
 			// Subsequently, if an identifier known to be an error is passed to
 			// ctx.Log().Something(), mark it as having been logged.
 			case *ast.CallExpr:
-				// We only care about call expressions to `ctx.Log().XYZ()`
-				if !receiverIsLogger(lintutil.ObjectFor(node.Fun, pass.TypesInfo)) {
+				// We only care about logging calls like `ctx.Log().XYZ()` (or
+				// the equivalent for other loggers; see log_loggers.go)
+				logger := _loggerFor(
+					lintutil.ObjectFor(node.Fun, pass.TypesInfo), _logOrReturnErrorCustomLoggers)
+				if logger == nil {
 					return true
 				}
 				// Within the call expression to ctx.Log().XYZ(), look for
@@ -0,0 +1,24 @@
+package linters_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/StevenACoffman/fixer/linters"
+)
+
+func TestLogOrReturnErrorLibraries(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), linters.LogOrReturnErrorAnalyzer, "logorreturn")
+}
+
+func TestLogOrReturnErrorCustomLogger(t *testing.T) {
+	setFlag(t, linters.LogOrReturnErrorAnalyzer, "logger",
+		"type=logorreturncustom.Printer,methods=Say,message=0,fields=key-value")
+	analysistest.Run(t, analysistest.TestData(), linters.LogOrReturnErrorAnalyzer, "logorreturncustom")
+
+	// The flag is log_or_return_error's own: it doesn't affect LogAnalyzer.
+	if value := linters.LogAnalyzer.Flags.Lookup("logger").Value.String(); value != "" {
+		t.Errorf("LogAnalyzer's -logger is %q, want it unset", value)
+	}
+}
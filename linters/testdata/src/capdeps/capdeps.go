@@ -0,0 +1,56 @@
+package capdeps
+
+import (
+	"github.com/Khan/webapp/pkg/kacontext"
+	"github.com/Khan/webapp/pkg/lib/log"
+)
+
+type Provider interface{ Provider() }
+
+type Base interface {
+	Provider
+	Name() string
+}
+
+type DB interface {
+	Provider
+	Query()
+}
+
+type Cache interface {
+	Provider
+	Get()
+}
+
+type Testing interface {
+	DB
+	Cache
+}
+
+// Base is a base, so it's fine to use only the root.
+func usesBase(deps interface { // want usesBase:`_capabilityUsage\(0: Name, Provider, Query\)`
+	Base
+	DB
+}) {
+	deps.Provider()
+	deps.Query()
+}
+
+func unusedCache(deps interface { // want unusedCache:`_capabilityUsage\(0: Provider, Query\)`
+	DB
+	Cache // want `deps requests but does not use interface\(s\) Cache; remove to use the smallest possible interface`
+}) {
+	deps.Query()
+}
+
+func exempt(deps Testing) {
+	deps.Query()
+}
+
+// context.Context isn't a root, so this isn't checked.
+func notRoot(ctx interface {
+	kacontext.Base
+	log.KAContext
+}) {
+	_ = ctx.Done()
+}
@@ -0,0 +1,44 @@
+package errorsas
+
+import (
+	"errors"
+	"os"
+)
+
+type valueError struct{}
+
+func (valueError) Error() string { return "value" }
+
+type pointerError struct{}
+
+func (*pointerError) Error() string { return "pointer" }
+
+type notAnError struct{}
+
+func valid(err error) {
+	var v valueError
+	var p *pointerError
+	var target interface{ Timeout() bool }
+	var anything interface{} = &v
+	var pathErr *os.PathError
+	_ = errors.As(err, &v)
+	_ = errors.As(err, &p)
+	_ = errors.As(err, &target)
+	_ = errors.As(err, anything)
+	_ = errors.As(err, &pathErr)
+}
+
+func invalid(err error) {
+	var v valueError
+	var p *pointerError
+	var pp pointerError
+	var e error
+	var n notAnError
+	_ = errors.As(err, nil) // want `The second argument of errors.As must be a non-nil pointer`
+	_ = errors.As(err, v)   // want `The second argument of errors.As must be a pointer, not valueError`
+	_ = errors.As(err, &e)  // want `The second argument of errors.As must not be a pointer to error: every error matches, so errors.As will always return true`
+	_ = errors.As(err, &pp) // want `The second argument of errors.As must point to an error, but only \*pointerError \(not pointerError\) implements error`
+	_ = errors.As(err, &n)  // want `The second argument of errors.As must point to a type implementing error or to an interface type, but notAnError does not implement error`
+	pt := &p
+	_ = errors.As(err, &pt) // want `The second argument of errors.As is a pointer to a pointer: \*\*pointerError does not implement error, but \*pointerError does`
+}
@@ -0,0 +1,13 @@
+package errorsrules
+
+import "errors"
+
+var errSentinel = errors.New("sentinel")
+
+func f(err error) {
+	local := errors.New("local")
+	_ = errors.Is(err, errSentinel)
+	_ = errors.Is(errSentinel, err)  // want `First argument to errors.Is needs to be a local variable` `Second argument to errors.Is cannot be a local variable`
+	_ = errors.Is(err, local)        // want `Second argument to errors.Is cannot be a local variable`
+	_ = errors.As(errSentinel, &err) // want `First argument to errors.As needs to be a local variable` `The second argument of errors.As must not be a pointer to error`
+}
@@ -0,0 +1,12 @@
+package errorsrulesoff
+
+import "errors"
+
+var errSentinel = errors.New("sentinel")
+
+func f(err error) {
+	local := errors.New("local")
+	_ = errors.Is(errSentinel, err) // want `First argument to errors.Is needs to be a local variable`
+	_ = errors.Is(err, local)
+	_ = errors.As(errSentinel, &err) // want `First argument to errors.As needs to be a local variable`
+}
@@ -0,0 +1,30 @@
+package genqlientgen
+
+import (
+	"context"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+type GetUserResponse struct{}
+
+// The query or mutation executed by GetUser.
+const GetUser_Operation = `
+query GetUserByID ($id: ID!) { user(id: $id) { name } }
+`
+
+func GetUser( // want GetUser:`_genqlientOperation\(GetUserByID\)`
+	ctx context.Context,
+	client graphql.Client,
+	id string,
+) (*GetUserResponse, error) {
+	return nil, nil
+}
+
+// The query or mutation executed by NotAnOperation.
+const NotAnOperation_Operation = `query NotAnOperation { me }`
+
+// NotAnOperation doesn't take a client, so it's not an operation-function.
+func NotAnOperation(ctx context.Context) (*GetUserResponse, error) {
+	return nil, nil
+}
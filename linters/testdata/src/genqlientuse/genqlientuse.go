@@ -0,0 +1,14 @@
+package genqlientuse
+
+import (
+	"context"
+
+	"genqlientgen"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+func f(ctx context.Context, client graphql.Client) {
+	genqlientgen.GetUser(ctx, client, "1") // want `(?s)opname: GetUserByID, document: \nquery GetUserByID \(\$id: ID!\) \{ user\(id: \$id\) \{ name \} \}\n`
+	genqlientgen.NotAnOperation(ctx)
+}
@@ -0,0 +1,4 @@
+// Package graphql is a stub of genqlient's runtime package for tests.
+package graphql
+
+type Client interface{}
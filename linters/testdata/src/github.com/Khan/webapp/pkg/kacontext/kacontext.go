@@ -0,0 +1,10 @@
+// Package kacontext is a stub of Khan's kacontext package for tests.
+package kacontext
+
+import "context"
+
+type kaContext struct{ context.Context }
+
+func Upgrade(ctx context.Context) *kaContext { return &kaContext{ctx} }
+
+type Base interface{ context.Context }
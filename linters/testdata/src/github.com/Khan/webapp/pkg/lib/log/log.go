@@ -0,0 +1,11 @@
+// Package log is a stub of Khan's log package for tests.
+package log
+
+type Fields map[string]interface{}
+
+type Logger struct{}
+
+func (*Logger) Info(msg string, fields ...Fields)  {}
+func (*Logger) Error(msg string, fields ...Fields) {}
+
+type KAContext interface{ Log() *Logger }
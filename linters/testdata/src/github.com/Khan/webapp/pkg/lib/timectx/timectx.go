@@ -0,0 +1,6 @@
+// Package timectx is a stub of Khan's timectx package for tests.
+package timectx
+
+import "time"
+
+type KAContext interface{ Time() time.Time }
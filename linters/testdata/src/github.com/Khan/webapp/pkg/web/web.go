@@ -0,0 +1,12 @@
+// Package web is a stub of Khan's web package for tests.
+package web
+
+import (
+	"github.com/Khan/webapp/pkg/lib/log"
+	"github.com/Khan/webapp/pkg/lib/timectx"
+)
+
+type RequestContext interface {
+	log.KAContext
+	timectx.KAContext
+}
@@ -0,0 +1,11 @@
+// Package zerolog is a stub of github.com/rs/zerolog for tests.
+package zerolog
+
+type Logger struct{}
+
+func (*Logger) Info() *Event { return &Event{} }
+
+type Event struct{}
+
+func (e *Event) Interface(key string, i interface{}) *Event { return e }
+func (*Event) Msg(msg string)                               {}
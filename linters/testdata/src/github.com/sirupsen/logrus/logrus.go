@@ -0,0 +1,16 @@
+// Package logrus is a stub of github.com/sirupsen/logrus for tests.
+package logrus
+
+type Fields map[string]interface{}
+
+type Entry struct{}
+
+func (e *Entry) Info(args ...interface{}) {}
+
+type Logger struct{}
+
+func (*Logger) WithFields(fields Fields) *Entry { return &Entry{} }
+func (*Logger) Info(args ...interface{})        {}
+
+func WithFields(fields Fields) *Entry { return &Entry{} }
+func Info(args ...interface{})        {}
@@ -0,0 +1,5 @@
+// Package bsontype is a stub of the mongo driver's bsontype package for
+// tests.
+package bsontype
+
+type Type byte
@@ -0,0 +1,15 @@
+// Package zap is a stub of go.uber.org/zap for tests.
+package zap
+
+type Field struct{}
+
+func Any(key string, value interface{}) Field { return Field{} }
+
+type Logger struct{}
+
+func (*Logger) Info(msg string, fields ...Field)  {}
+func (*Logger) Error(msg string, fields ...Field) {}
+
+type SugaredLogger struct{}
+
+func (*SugaredLogger) Infow(msg string, keysAndValues ...interface{}) {}
@@ -0,0 +1,49 @@
+package graphqldoc
+
+import (
+	"context"
+	"time"
+
+	"github.com/Khan/webapp/pkg/web/gqlclient"
+)
+
+type Time struct{}
+
+func (*Time) UnmarshalJSON([]byte) error { return nil }
+
+type userFields struct {
+	DatabaseID string
+	Email      string `graphql:"email"`
+}
+
+type userQuery struct {
+	User struct {
+		userFields
+		Created Time
+		Courses []struct {
+			Title string
+		} `graphql:"courses(first: $first)"`
+	} `graphql:"user(id: $id)"`
+}
+
+type recursive struct {
+	Parent *recursive
+}
+
+func f(ctx context.Context, client gqlclient.Client, opName string, variables map[string]interface{}, since time.Time) {
+	var query userQuery
+	client.Query(ctx, &query, "getUser", map[string]interface{}{"id": "1", "first": 10}) // want `opname: getUser, document: query getUser\(\$first:int!\$id:ID!\)\{user\(id: \$id\)\{databaseId,email,created,courses\(first: \$first\)\{title\}\}\}`
+
+	var mutation struct {
+		DoThing struct{ Ok bool } `graphql:"doThing"`
+	}
+	client.Mutate(ctx, &mutation, "doThing", nil) // want `opname: doThing, document: mutation doThing\{doThing\{ok\}\}`
+
+	client.Query(ctx, &query, "dynamicVars", variables)                                  // want `opname: dynamicVars, document error: unable to get GraphQL variables: non-literal argument`
+	client.Query(ctx, &query, "anyVars", map[string]interface{}{"id": interface{}("1")}) // want `opname: anyVars, document error: unable to get GraphQL variables: type of id is not known statically`
+
+	var r recursive
+	client.Query(ctx, &r, "recursive", nil) // want `opname: recursive, document error: unable to get GraphQL document: graphqldoc.recursive is recursive`
+
+	client.Query(ctx, &query, opName, nil) // want `error: unable to get GraphQL opname: non-constant argument`
+}
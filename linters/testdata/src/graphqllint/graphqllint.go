@@ -0,0 +1,15 @@
+package graphqllint
+
+import (
+	"context"
+
+	"github.com/Khan/webapp/pkg/web/gqlclient"
+)
+
+func f(ctx context.Context, client gqlclient.Client, opName string, variables map[string]interface{}) {
+	var query struct{ Me struct{ Name string } }
+	client.Query(ctx, &query, "getMe", nil)
+	// A document we can't reconstruct is not a problem.
+	client.Query(ctx, &query, "getMeWithVars", variables)
+	client.Query(ctx, &query, opName, nil) // want `unable to get GraphQL opname: non-constant argument`
+}
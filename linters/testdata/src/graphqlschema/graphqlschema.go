@@ -0,0 +1,51 @@
+package graphqlschema
+
+import (
+	"context"
+
+	"github.com/Khan/webapp/pkg/web/gqlclient"
+)
+
+type userQuery struct {
+	User struct {
+		Name string
+	} `graphql:"user(id: $id)"`
+}
+
+func f(ctx context.Context, client gqlclient.Client, variables map[string]interface{}) {
+	var query userQuery
+	client.Query(ctx, &query, "getUser", map[string]interface{}{"id": "1"})
+	// The same operation may be made twice.
+	client.Query(ctx, &query, "getUser", map[string]interface{}{"id": "2"})
+
+	var other struct {
+		User struct {
+			Emails []string
+		} `graphql:"user(id: $id)"`
+	}
+	client.Query(ctx, &other, "getUser", map[string]interface{}{"id": "1"}) // want `GraphQL operation-name getUser is already used at .*graphqlschema.go:17:2`
+	// We can't get the document with dynamic variables, so we don't know if
+	// it's the same.
+	client.Query(ctx, &other, "getUser", variables)
+	client.Query(ctx, &other, "getOther", variables)
+	client.Query(ctx, &other, "getOther", map[string]interface{}{"id": "1"})
+
+	var deprecated struct {
+		User struct {
+			Email string
+		} `graphql:"user(id: $id)"`
+	}
+	client.Query(ctx, &deprecated, "getEmail", map[string]interface{}{"id": "1"}) // want `GraphQL operation getEmail: field User.email is deprecated: use emails`
+
+	var unknown struct {
+		User struct {
+			Nickname string
+		} `graphql:"user(id: $id)"`
+	}
+	client.Query(ctx, &unknown, "getNickname", map[string]interface{}{"id": "1"}) // want `GraphQL operation getNickname: Cannot query field "nickname" on type "User".`
+
+	var mutation struct {
+		DeleteUser bool `graphql:"deleteUser(id: $id)"`
+	}
+	client.Mutate(ctx, &mutation, "deleteUser", map[string]interface{}{"id": 1}) // want `GraphQL operation deleteUser: Unknown type "int".` `GraphQL operation deleteUser: Variable "\$id" of type "int!" used in position expecting type "ID!".`
+}
@@ -0,0 +1,20 @@
+package jsondep
+
+import "encoding/json"
+
+type Reported struct { // want `JSONified type jsondep.Reported lacks explicit struct tags; .* fields missing tags: A` Reported:`_missingTags\(json: A\)`
+	A string
+}
+
+type Clean struct { // want Clean:`_missingTags\(json: \)`
+	A string `json:"a"`
+}
+
+type Unchecked struct {
+	B string
+}
+
+func f() {
+	json.Marshal(Reported{})
+	json.Marshal(Clean{})
+}
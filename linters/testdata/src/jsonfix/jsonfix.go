@@ -0,0 +1,37 @@
+package jsonfix
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Inner struct { // want Inner:`_missingTags\(json: ; yaml: \)`
+	Value string `json:"value" yaml:"value"`
+}
+
+type Name string // want Name:`_missingTags\(json: ; yaml: \)`
+
+type Outer struct { // want `JSONified type jsonfix.Outer lacks explicit struct tags; .* fields missing tags: Email, Name, UserID` `YAML-encoded type jsonfix.Outer lacks explicit struct tags; .* fields missing tags: Inner, Name, Ptr, UserID` Outer:`_missingTags\(json: Email, Name, UserID; yaml: Inner, Name, Ptr, UserID\)`
+	UserID string
+	Email  string `yaml:"email,omitempty"`
+	Inner
+	*Ptr
+	Name
+	Ignored string `json:"-" yaml:"-"`
+}
+
+type Ptr struct { // want Ptr:`_missingTags\(json: ; yaml: \)`
+	Value string `json:"value" yaml:"value"`
+}
+
+type Several struct { // want `JSONified type jsonfix.Several lacks explicit struct tags; .* fields missing tags: A, B` Several:`_missingTags\(json: A, B\)`
+	A, B string
+}
+
+func f() {
+	json.Marshal(Outer{})
+	yaml.Marshal(Outer{})
+	json.Marshal(Several{})
+	json.Marshal(struct{ HTTPStatus int }{}) // want `JSONified type struct{HTTPStatus int} lacks explicit struct tags; .* fields missing tags: HTTPStatus`
+}
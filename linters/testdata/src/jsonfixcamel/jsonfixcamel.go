@@ -0,0 +1,12 @@
+package jsonfixcamel
+
+import "encoding/json"
+
+type T struct { // want `JSONified type jsonfixcamel.T lacks explicit struct tags; .* fields missing tags: DatabaseID, HTMLURL` T:`_missingTags\(json: DatabaseID, HTMLURL\)`
+	DatabaseID string
+	HTMLURL    string
+}
+
+func f() {
+	json.Marshal(T{})
+}
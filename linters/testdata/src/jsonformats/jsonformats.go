@@ -0,0 +1,67 @@
+package jsonformats
+
+import (
+	"encoding/json"
+	"encoding/xml"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+type J struct { // want `JSONified type jsonformats.J lacks explicit struct tags; .* fields missing tags: A` J:`_missingTags\(json: A\)`
+	A string
+}
+
+type X struct { // want `XML-encoded type jsonformats.X lacks explicit struct tags; .* fields missing tags: A` X:`_missingTags\(xml: A\)`
+	XMLName xml.Name
+	A       string
+}
+
+type Y struct { // want `YAML-encoded type jsonformats.Y lacks explicit struct tags; .* fields missing tags: A` Y:`_missingTags\(yaml: A\)`
+	A string
+}
+
+type B struct { // want `BSON-encoded type jsonformats.B lacks explicit struct tags; .* fields missing tags: A` B:`_missingTags\(bson: A\)`
+	A string
+}
+
+type M struct { // want `msgpack-encoded type jsonformats.M lacks explicit struct tags; .* fields missing tags: A` M:`_missingTags\(msgpack: A\)`
+	A string
+}
+
+// A MarshalJSON method with the wrong signature doesn't make a Marshaler.
+type WrongSignature struct { // want `JSONified type jsonformats.WrongSignature lacks explicit struct tags; .* fields missing tags: A` WrongSignature:`_missingTags\(json: A\)`
+	A string
+}
+
+func (WrongSignature) MarshalJSON() string { return "" }
+
+type Marshaler struct{ A string }
+
+func (*Marshaler) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type NodeUnmarshaler struct{ A string }
+
+func (*NodeUnmarshaler) UnmarshalYAML(*yaml.Node) error { return nil }
+
+type FuncUnmarshaler struct{ A string }
+
+func (*FuncUnmarshaler) UnmarshalYAML(func(interface{}) error) error { return nil }
+
+type MsgpackEncoder struct{ A string }
+
+func (MsgpackEncoder) EncodeMsgpack(*msgpack.Encoder) error { return nil }
+
+func f() {
+	json.Marshal(J{})
+	xml.Marshal(X{})
+	yaml.Marshal(Y{})
+	bson.Marshal(B{})
+	msgpack.Marshal(M{})
+	json.Marshal(WrongSignature{})
+	json.Marshal(Marshaler{})
+	yaml.Unmarshal(nil, &NodeUnmarshaler{})
+	yaml.Unmarshal(nil, &FuncUnmarshaler{})
+	msgpack.Marshal(MsgpackEncoder{})
+}
@@ -0,0 +1,30 @@
+package jsontagged
+
+import "encoding/json"
+
+// Text encodes itself as a string, so needs no tags.
+type Text struct{ A string }
+
+func (Text) MarshalText() ([]byte, error) { return nil, nil }
+
+func (*Text) UnmarshalText([]byte) error { return nil }
+
+type Stored struct { // want `JSONified type jsontagged.Stored lacks explicit struct tags; .* fields missing tags: B` Stored:`_missingTags\(json \(decode\): B; json: B\)`
+	B string
+}
+
+type Entity struct {
+	Data  Stored `kadatastore_json:"data"`
+	Text  Text   `kadatastore_json:"text"`
+	Other string
+}
+
+// Partial has a json tag, so we assume it's JSONified somewhere.
+type Partial struct { // want `JSONified type jsontagged.Partial lacks explicit struct tags; .* fields missing tags: D` Partial:`_missingTags\(json: D\)`
+	C string `json:"c"`
+	D string
+}
+
+func f() {
+	json.Marshal(Text{})
+}
@@ -0,0 +1,20 @@
+package jsonuse
+
+import (
+	"encoding/json"
+
+	"jsondep"
+)
+
+// Wrapper's problems with jsondep.Reported were reported in jsondep.
+type Wrapper struct { // want Wrapper:`_missingTags\(json: \)`
+	Reported jsondep.Reported `json:"reported"`
+}
+
+func f() {
+	json.Marshal(Wrapper{})
+	json.Marshal(jsondep.Reported{})
+	json.Marshal(jsondep.Clean{})
+	json.Marshal(jsondep.Unchecked{})        // want `JSONified type jsondep.Unchecked lacks explicit struct tags; .* fields missing tags: B`
+	json.Unmarshal(nil, &jsondep.Reported{}) // want `JSONified type \*jsondep.Reported lacks explicit struct tags; .* fields missing tags: A`
+}
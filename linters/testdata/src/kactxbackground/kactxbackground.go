@@ -0,0 +1,36 @@
+package kactxbackground
+
+import (
+	"context"
+	"net/http"
+)
+
+func use(ctx context.Context) {}
+
+func withCtx(ctx context.Context) {
+	use(context.Background()) // want `do not use context.Background\(\) outside tests; use ctx instead`
+}
+
+func newContext() context.Context { return nil }
+
+func withOther() {
+	parent := newContext()
+	use(parent)
+	func() {
+		use(context.TODO()) // want `do not use context.TODO\(\) outside tests; use parent instead`
+	}()
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	use(context.Background()) // want `do not use context.Background\(\) outside tests; use r.Context\(\) instead`
+}
+
+func none() {
+	use(context.Background())   // want `do not use context.Background\(\) outside tests$`
+	ctx := context.Background() // want `do not use context.Background\(\) outside tests$`
+	use(ctx)
+}
+
+func init() {
+	use(context.Background())
+}
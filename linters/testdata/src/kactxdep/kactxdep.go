@@ -0,0 +1,25 @@
+package kactxdep
+
+import (
+	"github.com/Khan/webapp/pkg/kacontext"
+	"github.com/Khan/webapp/pkg/lib/log"
+	"github.com/Khan/webapp/pkg/lib/timectx"
+)
+
+type Context interface {
+	kacontext.Base
+	log.KAContext
+	timectx.KAContext
+}
+
+func Debug(ctx interface { // want Debug:`_capabilityUsage\(0: Deadline, Debug, Done, Err, Value\)`
+	kacontext.Base
+	Debug()
+}) {
+	ctx.Debug()
+}
+
+func LogOnly(ctx Context) { // want LogOnly:`_capabilityUsage\(0: Deadline, Done, Err, Log, Value\)` `ctx requests but does not use interface\(s\) timectx.KAContext`
+	ctx.Log()
+	_ = ctx.Done()
+}
@@ -0,0 +1,44 @@
+package kactxiface
+
+import (
+	"github.com/Khan/webapp/pkg/kacontext"
+	"github.com/Khan/webapp/pkg/lib/log"
+	"github.com/Khan/webapp/pkg/web"
+)
+
+func removeUnused(ctx interface { // want removeUnused:`_capabilityUsage\(0: Deadline, Done, Err, Log, Value\)`
+	kacontext.Base
+	log.KAContext
+	web.RequestContext // want `ctx requests but does not use interface\(s\) timectx.KAContext; remove to use the smallest possible interface`
+}) {
+	ctx.Log()
+	_ = ctx.Done()
+}
+
+func addUnrequested(ctx interface { // want addUnrequested:`_capabilityUsage\(0: Deadline, Done, Err, Time, Value\)` `ctx uses but does not explicitly request interface\(s\) timectx.KAContext; add it explicitly`
+	kacontext.Base
+	web.RequestContext
+}) {
+	_ = ctx.Time()
+	_ = ctx.Done()
+}
+
+func allUnused(ctx interface { // want allUnused:`_capabilityUsage\(0: \)` `no interfaces requested by ctx are used`
+	kacontext.Base
+	log.KAContext
+}) {
+}
+
+type named interface {
+	kacontext.Base
+	log.KAContext
+}
+
+func namedType(ctx named) { // want namedType:`_capabilityUsage\(0: Deadline, Done, Err, Value\)` `ctx requests but does not use interface\(s\) log.KAContext`
+	_ = ctx.Done()
+}
+
+func notChecked(ctx web.RequestContext) {
+	ctx.Log()
+	_ = ctx.Time()
+}
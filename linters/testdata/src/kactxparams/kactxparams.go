@@ -0,0 +1,38 @@
+package kactxparams
+
+import "context"
+
+type runner interface {
+	run(n int, ctx context.Context)
+}
+
+type T struct{}
+
+// run is needed to implement runner, so we can't reorder it.
+func (*T) run(n int, ctx context.Context) {} // want `Context should be the first parameter`
+
+func (T) other(n int, ctx context.Context) {} // want `Context should be the first parameter`
+
+// Callers in other packages would break.
+func Exported(n int, ctx context.Context) {} // want `Context should be the first parameter`
+
+func helper(n int, c context.Context, s string) {} // want `Context should be the first parameter` `Context parameter should be called 'ctx'`
+
+func callback(n int, ctx context.Context) {} // want `Context should be the first parameter`
+
+func conflict(c context.Context) { // want `Context parameter should be called 'ctx'`
+	ctx := 1
+	_, _ = ctx, c
+}
+
+func caller(ctx context.Context) {
+	var r runner = &T{}
+	r.run(1, ctx)
+	T{}.other(2, ctx)
+	T.other(T{}, 6, ctx)
+	helper(3,
+		ctx, "x")
+	(helper)(4, ctx, "y")
+	f := callback
+	f(5, ctx)
+}
@@ -0,0 +1,10 @@
+package kactxtodo
+
+import "context"
+
+func use(ctx context.Context) {}
+
+func f() {
+	use(context.TODO())
+	use(context.Background()) // want `do not use context.Background\(\) outside tests`
+}
@@ -0,0 +1,79 @@
+package kactxupgrade
+
+import (
+	"context"
+	nethttp "net/http"
+
+	"github.com/Khan/webapp/pkg/kacontext"
+	"github.com/graph-gophers/dataloader"
+)
+
+func handler(w nethttp.ResponseWriter, r *nethttp.Request) {
+	use(kacontext.Upgrade(r.Context()))
+}
+
+//ka:context-root
+func annotated(ctx context.Context) {
+	use(kacontext.Upgrade(ctx))
+}
+
+//ka:context-root (an explanation may follow the directive)
+func annotatedWithReason(ctx context.Context) {
+	use(kacontext.Upgrade(ctx))
+}
+
+//ka:context-rootish
+func notAnnotated(ctx context.Context) {
+	use(kacontext.Upgrade(ctx)) // want `Producing the 'kaContext' type \(such as with kacontext.Upgrade\) is not allowed in this function`
+}
+
+// The dataloader is vendored, and its Result is aliased, but this is still a
+// batch function.
+type result = dataloader.Result
+
+func batch(ctx context.Context, keys []string) []*result {
+	use(kacontext.Upgrade(ctx))
+
+	return nil
+}
+
+type Server struct{}
+
+// Allowed via -kacontext.upgrade-allowed.
+func (*Server) HandleThing(ctx context.Context) {
+	use(kacontext.Upgrade(ctx))
+}
+
+func (*Server) other(ctx context.Context) {
+	use(kacontext.Upgrade(ctx)) // want `Producing the 'kaContext' type \(such as with kacontext.Upgrade\) is not allowed in this function`
+	_ = func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		use(kacontext.Upgrade(r.Context()))
+	}
+	// Only the HTTP handler rule applies to function literals.
+	_ = func(keys []string) []*dataloader.Result {
+		use(kacontext.Upgrade(ctx)) // want `Producing the 'kaContext' type \(such as with kacontext.Upgrade\) is not allowed in this function`
+
+		return nil
+	}
+}
+
+// Allowed via -kacontext.context-root.
+type Job struct{}
+
+func runJob(ctx context.Context, job *Job) {
+	use(kacontext.Upgrade(ctx))
+}
+
+func jobs(ctx context.Context) {
+	_ = func(job *Job) {
+		use(kacontext.Upgrade(ctx))
+	}
+	// Allowed via -kacontext.context-root-callees.
+	register(func() {
+		use(kacontext.Upgrade(ctx))
+	})
+}
+
+func register(f func()) {}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,28 @@
+package kactxuse
+
+import (
+	"kactxdep"
+
+	"github.com/Khan/webapp/pkg/kacontext"
+	"github.com/Khan/webapp/pkg/lib/log"
+	"github.com/Khan/webapp/pkg/lib/timectx"
+)
+
+// kactxdep.Debug only uses Debug, which is explicit in our interface, so we
+// use all of it.
+func debug(ctx interface { // want debug:`_capabilityUsage\(0: Deadline, Debug, Done, Err, Log, Value\)`
+	kacontext.Base
+	log.KAContext
+	Debug()
+}) {
+	kactxdep.Debug(ctx)
+}
+
+// kactxdep.LogOnly doesn't use timectx.KAContext, but we need it to call it.
+func logOnly(ctx interface { // want logOnly:`_capabilityUsage\(0: Deadline, Done, Err, Log, Time, Value\)`
+	kacontext.Base
+	log.KAContext
+	timectx.KAContext
+}) {
+	kactxdep.LogOnly(ctx)
+}
@@ -0,0 +1,18 @@
+package kvargs
+
+import "kvargsdep"
+
+func f(logger kvargsdep.Logger, key string) {
+	kvargsdep.Log("msg", "a", 1, "b", 2)
+	kvargsdep.Log("msg", "a")            // want `kvargsdep.Log\(\) should have an odd number of arguments, not 2`
+	kvargsdep.Log("msg", "a", 1, "a", 2) // want `kvargsdep.Log\(\) has duplicate key "a" \(args 1 and 3\)`
+	logger.With(key, 1)                  // want `Logger.With\(\) should use string-literals as keys, but arg 0 has type string`
+	logger.With("a", 1, "b")             // want `Logger.With\(\) should have an even number of arguments, not 3`
+	kvargsdep.Log("msg", "userID", 1)    // want "kvargsdep.Log\\(\\) key \"userID\" does not match the pattern `\\^\\[a-z_\\]\\+\\$`"
+	kvargsdep.Pair("a", 1, "b")          // want `kvargsdep.Pair\(\) should have an even number of arguments, not 3`
+	kvargsdep.Bad("a")
+	kvargsdep.NotADirective("a")
+	kvargsdep.NoStart("a")
+	kvargsdep.NotVariadic("a", 1)
+	kvargsdep.OutOfRange("msg", "a")
+}
@@ -0,0 +1,43 @@
+package kvargsdep
+
+// Log logs the message with the given key/value pairs.
+//
+//fixer:kvargs start=1
+func Log(msg string, kvs ...interface{}) {} // want Log:`_kvArgs\(1\)`
+
+type Logger interface {
+	// With returns a logger with the given key/value pairs.
+	//
+	//fixer:kvargs start=0
+	With(kvs ...interface{}) Logger // want With:`_kvArgs\(0\)`
+}
+
+// Bad has an invalid directive.
+//
+//fixer:kvargs first=1 // want `invalid //fixer:kvargs option "first=1": expected start=<index>`
+func Bad(kvs ...interface{}) {}
+
+// Pair's first key is its first parameter.
+//
+//fixer:kvargs start=0
+func Pair(key string, value interface{}, kvs ...interface{}) {} // want Pair:`_kvArgs\(0\)`
+
+// NotADirective doesn't take key/value pairs.
+//
+//fixer:kvargsish start=0
+func NotADirective(args ...interface{}) {}
+
+// NoStart has no start option.
+//
+//fixer:kvargs // want `//fixer:kvargs requires start=<index>`
+func NoStart(kvs ...interface{}) {}
+
+// NotVariadic can't take key/value varargs.
+//
+//fixer:kvargs start=0 // want `//fixer:kvargs function NotVariadic is not variadic`
+func NotVariadic(key string, value interface{}) {}
+
+// OutOfRange has only two parameters.
+//
+//fixer:kvargs start=2 // want `//fixer:kvargs start=2 is out of range: OutOfRange has 2 parameters`
+func OutOfRange(msg string, kvs ...interface{}) {}
@@ -0,0 +1,18 @@
+package kvwrap
+
+import (
+	"github.com/Khan/webapp/pkg/lib/errors"
+)
+
+const keyConst = "key_const"
+
+func f(err, other error, key string) {
+	_ = errors.Wrap(err, "user_id", 1, "email", "e")
+	_ = errors.Wrap(err, "user_id")                      // want `errors.KhanWrap\(\) should have an odd number of arguments, not 2`
+	_ = errors.Wrap(err, keyConst, 1)                    // want `errors.KhanWrap\(\) should use string-literals as keys, but arg 1 has type string`
+	_ = errors.Wrap(err, key, 1)                         // want `errors.KhanWrap\(\) should use string-literals as keys, but arg 1 has type string`
+	_ = errors.Wrap(err, "user_id", 1, "user_id", 2)     // want `errors.KhanWrap\(\) has duplicate key "user_id" \(args 1 and 3\)`
+	_ = errors.Wrap(err, "userID", 1)                    // want "errors.KhanWrap\\(\\) key \"userID\" does not match the pattern `\\^\\[a-z_\\]\\+\\$`"
+	_ = errors.Wrap(err, "other", other)                 // want `errors.KhanWrap\(\) value for key "other" is an error: the error being wrapped should be the first argument`
+	_ = errors.Wrap(err, "nil_error", nil, "other", nil) // ok: nil isn't an error value
+}
@@ -0,0 +1,30 @@
+package linewrapcalls
+
+type point struct {
+	X, Y, Longer int
+}
+
+var (
+	alpha, beta, gamma, delta int
+	values                    []int
+)
+
+func combine(vs ...int) int {
+	return len(vs)
+}
+
+func sum(a, b, c int, rest ...int) int {
+	return a + b + c + combine(rest...)
+}
+
+func calls() {
+	_ = combine(alpha, beta, gamma, delta) //nolint:lll // want `line is 42 characters`
+
+	_ = combine(combine(alpha, beta), gamma, delta) //nolint:lll // want `line is 51 characters`
+
+	_ = sum(alpha, beta, gamma, values...) //nolint:lll // want `line is 42 characters`
+
+	_ = point{X: alpha, Y: beta, Longer: gamma} //nolint:lll // want `line is 47 characters`
+
+	_ = combine(alpha, beta /* gamma */, delta) //nolint:lll // want `line is 47 characters`
+}
@@ -0,0 +1,16 @@
+package linewrapcfg
+
+var alpha, beta, gamma, delta, epsilon, zeta int
+
+// A short comment is fine.
+var short = alpha + beta
+
+// This comment is long enough that it needs wrapping. // want `comment line is \d+ characters`
+var long = alpha + beta + gamma + delta + epsilon + zeta //nolint:lll // want `line is 56 characters`
+
+// Tabs count as eight characters.
+func indented() int {
+	return alpha + beta + gamma + delta + epsilon //nolint:lll // want `line is 53 characters`
+}
+
+var exempt = alpha + beta + gamma + delta + epsilon + zeta
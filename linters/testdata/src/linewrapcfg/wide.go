@@ -0,0 +1,6 @@
+package linewrapcfg
+
+// This file has a larger limit, so this comment fits.
+var wide = alpha + beta + gamma + delta + epsilon + zeta
+
+var wider = alpha + beta + gamma + delta + epsilon + zeta + alpha + beta //nolint:lll // want `line is 72 characters`
@@ -0,0 +1,19 @@
+package linewrapdoc
+
+// Doc has a doc comment whose first paragraph is too long to fit. // want "comment line"
+//
+// # A heading which is too long to fit but is kept
+//
+// A list:
+//   - the first item, which is long enough to need wrapping // want "comment line"
+//   - a short item
+//
+// A numbered list:
+//  10. a numbered item which is also too long to fit // want "comment line"
+//
+// Code:
+//
+//	x := someFunction(withArguments, thatAreTooLongToFit)
+//
+// [a link]: https://example.com/a/path/that/is/too/long/to/fit
+func Doc() {}
@@ -0,0 +1,18 @@
+package linewrapfuncs
+
+func sameLine(alpha, beta int) int { return alpha + beta } // want `function line is \d+ characters`
+
+func params(alpha, beta, gamma, delta int, epsilon string) error { // want `function line is \d+ characters`
+	return nil
+}
+
+func noParams() (first, second int, third string, err error) { // want `function line is \d+ characters`
+	return 0, 0, "", nil
+}
+
+func multiLine(
+	alpha int, // the first
+	beta int,
+) (first, second int, third string, fourth bool, err error) { // want `function line is \d+ characters`
+	return alpha, beta, "", false, nil
+}
@@ -0,0 +1,32 @@
+package linewrapfuncsfixed
+
+func sameLine(alpha, beta int) int {
+	return alpha + beta
+}
+
+func params(
+	alpha, beta, gamma, delta int,
+	epsilon string,
+) error {
+	return nil
+}
+
+func noParams() (
+	first, second int,
+	third string,
+	err error,
+) {
+	return 0, 0, "", nil
+}
+
+func multiLine(
+	alpha int, // the first
+	beta int,
+) (
+	first, second int,
+	third string,
+	fourth bool,
+	err error,
+) {
+	return alpha, beta, "", false, nil
+}
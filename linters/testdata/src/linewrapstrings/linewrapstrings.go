@@ -0,0 +1,17 @@
+package linewrapstrings
+
+//go:generate echo "a directive with a long string we never touch"
+
+type tagged struct {
+	Field string `json:"field" yaml:"a long struct tag we never touch"`
+}
+
+var greeting = "hello there, this is a long greeting to split" // want `line is \d+ characters`
+
+var escaped = "tab\there, then\na newlineé and more words" // want `line is \d+ characters`
+
+var raw = `a long raw string which we never split at all`
+
+var indexed = "a long string which we index into"[0] // want `line is \d+ characters`
+
+var word = "supercalifragilisticexpialidocious-and-more"
@@ -0,0 +1,14 @@
+package logcustom
+
+import "fmt"
+
+type Printer struct{}
+
+func (Printer) Say(level int, msg string, keysAndValues ...interface{}) {}
+
+func (Printer) Format(msg string) string { return msg }
+
+func custom(p Printer, u string) {
+	p.Say(1, fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+	_ = p.Format(fmt.Sprintf("hello %s", u))
+}
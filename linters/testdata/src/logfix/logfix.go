@@ -0,0 +1,33 @@
+package logfix
+
+import (
+	"fmt"
+
+	"github.com/Khan/webapp/pkg/lib/log"
+)
+
+type request struct {
+	ID    string
+	Count int
+}
+
+func (r request) UserName() string { return r.ID }
+
+func inline(logger *log.Logger, u string, req request) {
+	logger.Info(fmt.Sprintf("user %s did %d things", u, req.Count))     // want `avoid using Sprintf to log; instead use a fixed message with fields`
+	logger.Error(fmt.Sprintf("loading %q: %v", req.UserName(), req.ID)) // want `avoid using Sprintf to log`
+}
+
+func stored(logger *log.Logger, userID string) {
+	msg := fmt.Sprintf("hello %s", userID)
+	logger.Info(msg) // want `avoid using Sprintf \(at .*logfix.go:22:9\) to log`
+}
+
+// We only report these.
+func tooComplex(logger *log.Logger, u string, a, b int) {
+	logger.Info(fmt.Sprintf("%[1]s and %[1]s", u)) // want `avoid using Sprintf to log`
+	logger.Info(fmt.Sprintf("sum %d", a+b))        // want `avoid using Sprintf to log`
+	msg := fmt.Sprintf("hello %s", u)
+	u = "someone else"
+	logger.Info(msg) // want `avoid using Sprintf \(at .*\) to log`
+}
@@ -0,0 +1,10 @@
+package logfiximport
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+func hello(u string) {
+	slog.Info(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+}
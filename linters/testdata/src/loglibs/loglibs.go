@@ -0,0 +1,31 @@
+package loglibs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/zap"
+)
+
+func withSlog(ctx context.Context, logger *slog.Logger, u string) {
+	slog.Info(fmt.Sprintf("hello %s", u))               // want `avoid using Sprintf to log`
+	logger.InfoContext(ctx, fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+	slog.Info(fmt.Sprintf("%[1]s and %[1]s", u))        // want `avoid using Sprintf to log`
+}
+
+func withZap(logger *zap.Logger, sugar *zap.SugaredLogger, u string) {
+	logger.Info(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+	sugar.Infow(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+}
+
+func withZerolog(logger *zerolog.Logger, u string) {
+	logger.Info().Msg(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+}
+
+func withLogrus(logger *logrus.Logger, u string) {
+	logger.Info(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+	logrus.Info(fmt.Sprintf("hello %s", u)) // want `avoid using Sprintf to log`
+}
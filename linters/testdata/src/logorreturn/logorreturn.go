@@ -0,0 +1,38 @@
+package logorreturn
+
+import (
+	"errors"
+	"log/slog"
+
+	"go.uber.org/zap"
+)
+
+func do() error { return errors.New("failed") }
+
+func withSlog(logger *slog.Logger) error {
+	err := do()
+	if err != nil {
+		logger.Error("failed", "err", err)
+		return err // want `Errors may be logged or returned, but not both`
+	}
+
+	return nil
+}
+
+func withZap(logger *zap.Logger) error {
+	err := do()
+	if err != nil {
+		logger.Error("failed", zap.Any("err", err))
+		logger.Error("failed again", zap.Any("err", err)) // want `This error is being logged twice`
+	}
+
+	return nil
+}
+
+// Say isn't a logging method, so this is fine.
+func custom(p interface{ Say(msg string, err error) }) error {
+	err := do()
+	p.Say("failed", err)
+
+	return err
+}
@@ -0,0 +1,21 @@
+package logorreturncustom
+
+import (
+	"errors"
+)
+
+type Printer struct{}
+
+func (Printer) Say(msg string, keysAndValues ...interface{}) {}
+
+func do() error { return errors.New("failed") }
+
+func custom(p Printer, u string) error {
+	err := do()
+	if err != nil {
+		p.Say("failed", "user", u, "err", err)
+		return err // want `Errors may be logged or returned, but not both`
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 
@@ -61,6 +62,16 @@ import (
 )
 
 func main() {
+	// Subcommands, which don't run the linters in the usual way.
+	if len(os.Args) > 1 && os.Args[1] == "graphql-ops" {
+		if err := runGraphQLOps(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	var runKhan bool
 	flag.BoolVar(&runKhan, "khan", false, "run khan specific linters")
 	for _, a := range os.Args[1:] {
@@ -138,6 +149,7 @@ func main() {
 		checks = append(checks, linters.ErrorsWrapAnalyzer)
 		checks = append(checks, linters.GraphQLAnalyzer)
 		checks = append(checks, linters.GraphQLLintAnalyzer)
+		checks = append(checks, linters.GraphQLSchemaAnalyzer)
 		checks = append(checks, linters.GraphQLTestAnalyzer)
 		checks = append(checks, linters.HTTPReturnAnalyzer)
 		checks = append(checks, linters.JSONTagAnalyzer)
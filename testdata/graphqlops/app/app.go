@@ -0,0 +1,22 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/Khan/webapp/pkg/web/gqlclient"
+
+	"example.com/graphqlops/generated"
+)
+
+func f(ctx context.Context, client gqlclient.Client, genqlientClient graphql.Client, opName string) {
+	var mutation struct {
+		DoThing struct{ Ok bool } `graphql:"doThing"`
+	}
+	_ = client.Mutate(ctx, &mutation, "doThing", nil)
+
+	var query struct{ Me struct{ Name string } }
+	_ = client.Query(ctx, &query, opName, nil)
+
+	_, _ = generated.GetUser(ctx, genqlientClient, "1")
+}
@@ -0,0 +1,16 @@
+package generated
+
+import (
+	"context"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+type GetUserResponse struct{}
+
+// The query or mutation executed by GetUser.
+const GetUser_Operation = `query GetUserByID ($id: ID!) { user(id: $id) { name } }`
+
+func GetUser(ctx context.Context, client graphql.Client, id string) (*GetUserResponse, error) {
+	return nil, nil
+}
@@ -0,0 +1,13 @@
+module example.com/graphqlops
+
+go 1.22
+
+require (
+	github.com/Khan/genqlient v0.0.0
+	github.com/Khan/webapp v0.0.0
+)
+
+replace (
+	github.com/Khan/genqlient => ./stubs/genqlient
+	github.com/Khan/webapp => ./stubs/webapp
+)
@@ -0,0 +1,3 @@
+module github.com/Khan/webapp
+
+go 1.22